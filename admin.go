@@ -0,0 +1,309 @@
+package tec
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/agilecho/tec/cron"
+	"github.com/agilecho/tec/metrics"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var adminStart = time.Now()
+
+var adminVisible = []string{"name", "host", "port", "proxy", "static", "tls", "lang", "compresstypes", "domain", "path", "prefix", "type", "extension", "define", "layout", "error", "origin", "methods", "headers", "expose", "allow", "exts", "types", "offload", "root", "user", "database", "charset", "deploy", "slave", "vhost", "exchange", "logs", "version", "appid", "sdkappid", "mchid", "accounttype", "notify"}
+
+var adminContainers = []string{"rules", "mounts", "schedules", "cache"}
+
+type adminHandler struct {
+	app *App
+	prefix string
+	token string
+	allow []*net.IPNet
+}
+
+func (this *adminHandler) json(rep http.ResponseWriter, code int, data interface{}) {
+	result, _ := json.MarshalIndent(data, "", "  ")
+
+	rep.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rep.WriteHeader(code)
+	rep.Write(result)
+}
+
+func (this *adminHandler) allowed(req *http.Request) bool {
+	if this.token != "" {
+		token := req.Header.Get("X-Admin-Token")
+		if token == "" && strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+			token = req.Header.Get("Authorization")[7:]
+		}
+
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(this.token)) == 1 {
+			return true
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range this.allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *adminHandler) ServeHTTP(rep http.ResponseWriter, req *http.Request) {
+	this.serve(rep, req, strings.TrimPrefix(req.URL.Path, this.prefix))
+}
+
+func (this *adminHandler) serve(rep http.ResponseWriter, req *http.Request, name string) {
+	if !this.allowed(req) {
+		this.json(rep, http.StatusForbidden, Result{Code: http.StatusForbidden, Msg: "forbidden"})
+		return
+	}
+
+	name = strings.Trim(name, "/")
+
+	switch {
+	case name == "":
+		this.json(rep, http.StatusOK, Result{Code: http.StatusOK, Msg: "ok", Data: []string{"pprof/", "goroutine", "heap", "runtime", "config", "routes", "cron", "ws", "metrics"}})
+	case name == "pprof" || strings.HasPrefix(name, "pprof/"):
+		this.pprof(rep, req, strings.TrimPrefix(strings.TrimPrefix(name, "pprof"), "/"))
+	case name == "goroutine":
+		this.profile(rep, req, "goroutine", "2")
+	case name == "heap":
+		runtime.GC()
+		this.profile(rep, req, "heap", "1")
+	case name == "runtime":
+		this.json(rep, http.StatusOK, adminRuntime())
+	case name == "config":
+		this.json(rep, http.StatusOK, adminConfig(this.app.Config))
+	case name == "routes":
+		this.json(rep, http.StatusOK, adminRoutes(this.app.Router))
+	case name == "cron":
+		entries := []cron.Entry{}
+		if this.app.Config.Cron != nil {
+			entries = cron.Entries()
+		}
+
+		this.json(rep, http.StatusOK, entries)
+	case name == "ws":
+		this.json(rep, http.StatusOK, map[string]int{"connections": this.app.ws.Count()})
+	case name == "metrics":
+		rep.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.Write(rep)
+	default:
+		this.json(rep, http.StatusNotFound, Result{Code: http.StatusNotFound, Msg: "can not find admin path:" + name})
+	}
+}
+
+func (this *adminHandler) profile(rep http.ResponseWriter, req *http.Request, name string, debug string) {
+	tmp := *req.URL
+	tmp.RawQuery = "debug=" + debug
+
+	clone := *req
+	clone.URL = &tmp
+	clone.Form = nil
+
+	pprof.Handler(name).ServeHTTP(rep, &clone)
+}
+
+func (this *adminHandler) pprof(rep http.ResponseWriter, req *http.Request, name string) {
+	switch name {
+	case "cmdline":
+		pprof.Cmdline(rep, req)
+	case "profile":
+		pprof.Profile(rep, req)
+	case "symbol":
+		pprof.Symbol(rep, req)
+	case "trace":
+		pprof.Trace(rep, req)
+	default:
+		tmp := *req.URL
+		tmp.Path = "/debug/pprof/" + name
+
+		clone := *req
+		clone.URL = &tmp
+
+		pprof.Index(rep, &clone)
+	}
+}
+
+func adminRuntime() map[string]interface{} {
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+
+	return map[string]interface{}{
+		"version": runtime.Version(),
+		"uptime": time.Since(adminStart).String(),
+		"cpu": runtime.NumCPU(),
+		"maxprocs": runtime.GOMAXPROCS(0),
+		"goroutines": runtime.NumGoroutine(),
+		"alloc": stats.Alloc,
+		"total_alloc": stats.TotalAlloc,
+		"sys": stats.Sys,
+		"heap_alloc": stats.HeapAlloc,
+		"heap_inuse": stats.HeapInuse,
+		"heap_objects": stats.HeapObjects,
+		"num_gc": stats.NumGC,
+		"pause_total": time.Duration(stats.PauseTotalNs).String(),
+	}
+}
+
+func adminMask(key string, value interface{}) interface{} {
+	name := strings.ToLower(key)
+
+	switch value.(type) {
+	case map[string]interface{}:
+		if InArray(name, adminContainers) {
+			return value
+		}
+
+		for k, v := range value.(map[string]interface{}) {
+			value.(map[string]interface{})[k] = adminMask(k, v)
+		}
+	case []interface{}:
+		for i, v := range value.([]interface{}) {
+			value.([]interface{})[i] = adminMask(key, v)
+		}
+	case string:
+		if value.(string) != "" && !InArray(name, adminVisible) {
+			return "******"
+		}
+	}
+
+	return value
+}
+
+func adminConfig(config *Config) interface{} {
+	result, _ := json.Marshal(config)
+
+	data := map[string]interface{}{}
+	json.Unmarshal(result, &data)
+
+	if config.Extend != nil {
+		extend := map[string]interface{}{}
+		for section, node := range config.Extend.data {
+			items := map[string]interface{}{}
+			for key, value := range node {
+				items[key] = adminMask(key, value)
+			}
+
+			extend[section] = items
+		}
+
+		data["Extend"] = extend
+	}
+
+	return adminMask("", data)
+}
+
+func adminRoutes(router *Router) []map[string]interface{} {
+	routes := []map[string]interface{}{}
+	if router.table == nil {
+		return routes
+	}
+
+	for _, route := range router.table.routes {
+		routes = append(routes, map[string]interface{}{"path": route.Path, "methods": route.Methods, "name": route.name})
+	}
+
+	return routes
+}
+
+func adminNetworks(allow string) []*net.IPNet {
+	networks := []*net.IPNet{}
+
+	for _, item := range strings.Split(allow, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			if strings.Contains(item, ":") {
+				item += "/128"
+			} else {
+				item += "/32"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			Logger("admin allow invalid:" + item, "error")
+			continue
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func (this *App) adminHandler() *adminHandler {
+	token := ""
+	if this.Config.App != nil {
+		token = this.Config.App.Token
+	}
+
+	prefix := strings.TrimRight(this.Config.Admin.Path, "/")
+	if prefix == "" {
+		prefix = "/debug"
+	}
+
+	return &adminHandler{app: this, prefix: prefix, token: token, allow: adminNetworks(this.Config.Admin.Allow)}
+}
+
+func (this *App) adminRoutes() {
+	if this.Config.Admin == nil || this.Config.Admin.Port > 0 {
+		return
+	}
+
+	handler := this.adminHandler()
+
+	this.Router.REQUEST(handler.prefix + "/*name", func(ctx *Context) {
+		handler.serve(ctx.Response, ctx.Request, ctx.PathParam["name"])
+	})
+}
+
+func (this *App) adminServe(host string) {
+	if this.Config.Admin == nil || this.Config.Admin.Port <= 0 {
+		return
+	}
+
+	if this.Config.Admin.Host != "" {
+		host = this.Config.Admin.Host
+	}
+
+	handler := this.adminHandler()
+
+	mux := http.NewServeMux()
+	mux.Handle(handler.prefix + "/", handler)
+	mux.Handle(handler.prefix, handler)
+
+	this.admin = &http.Server{Addr: host + ":" + strconv.Itoa(this.Config.Admin.Port), Handler: mux}
+
+	fmt.Println("admin: " + this.admin.Addr)
+
+	go func() {
+		err := this.admin.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			Logger("app.admin error:" + err.Error(), "error", "false")
+		}
+	}()
+}
@@ -12,6 +12,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +25,7 @@ type Handler func(ctx *Context)
 type StartFunc func(app *App)
 type BeforeFilterFunc func(ctx *Context) bool
 type AfterFilterFunc func(ctx *Context, method string, data interface{})
+type ShutdownFunc func(ctx context.Context)
 
 type Result struct {
 	Code int `json:"code"`
@@ -45,16 +48,29 @@ type App struct {
 
 	startFunc *StartFunc
 	emptyFunc *Handler
+	methodFunc *Handler
+	errorFunc *ErrorFunc
 
 	beforeFilter []BeforeFilterFunc
 	afterFilter []AfterFilterFunc
 
 	events map[string]interface{}
+	shutdownFunc []ShutdownFunc
+	healthFunc []healthCheck
+	bare map[string]bool
+	globalFunc []GlobalFunc
+	templates *templateManager
+	done chan struct{}
 
 	pool *sync.Pool
 	ws ws.Server
+	wsOnce sync.Once
 
 	srv http.Server
+	redirect *http.Server
+	admin *http.Server
+	static *staticHandler
+	statics []*staticHandler
 }
 
 func (this *App) Before(filter BeforeFilterFunc) {
@@ -77,6 +93,10 @@ func (this *App) Empty(fun Handler) {
 	this.emptyFunc = &fun
 }
 
+func (this *App) NotAllowed(fun Handler) {
+	this.methodFunc = &fun
+}
+
 func (this *App) Bind(event string, callback interface{}) {
 	this.events[event] = callback
 }
@@ -90,6 +110,10 @@ func (this *App) init() {
 
 	CONFIG = this.Config
 
+	if this.done == nil {
+		this.done = make(chan struct{})
+	}
+
 	if this.Config.Redis != nil {
 		cache.Init(this.Config.Redis)
 	}
@@ -99,10 +123,14 @@ func (this *App) init() {
 
 		go func() {
 			pring := time.NewTicker(3600 * time.Second)
+			defer pring.Stop()
+
 			for {
 				select {
 				case <- pring.C:
 					db.Ping()
+				case <- this.done:
+					return
 				}
 			}
 		}()
@@ -125,16 +153,37 @@ func (this *App) init() {
 		sessionStart()
 	}
 
+	if this.Config.Cors != nil {
+		this.beforeFilter = append([]BeforeFilterFunc{corsFilter(this.Config.Cors)}, this.beforeFilter...)
+	}
+
 	if this.startFunc != nil {
 		(*this.startFunc)(this)
 	}
 
+	go this.resumableCollect()
+
+	if this.Config.Limit != nil {
+		this.beforeFilter = append(this.beforeFilter, limitRules(this.Config.Limit))
+	}
+
+	this.healthRoutes()
+	this.metricsRoutes()
+	this.adminRoutes()
+
 	if this.emptyFunc == nil {
 		this.Empty(func(ctx *Context) {
 			ctx.Json(Result{Code: 404, Msg: "can not find handler path:" + ctx.Path + " method:" + ctx.Method})
 		})
 	}
 
+	if this.methodFunc == nil {
+		this.NotAllowed(func(ctx *Context) {
+			ctx.Status(http.StatusMethodNotAllowed)
+			ctx.Json(Result{Code: 405, Msg: "method not allowed path:" + ctx.Path + " method:" + ctx.Method})
+		})
+	}
+
 	this.pool = &sync.Pool{
 		New: func() interface{} {
 			return &Context{}
@@ -143,20 +192,45 @@ func (this *App) init() {
 }
 
 func (this *App) Handler(rep http.ResponseWriter, req *http.Request) {
-	if this.Config.App == nil || !this.Config.App.Debug {
-		defer Exception("App Handler ")
-	}
-
 	if req.RequestURI == "/favicon.ico" {
 		return
 	}
 
-	context := &Context{afterFilter: this.afterFilter}
+	start := time.Now()
+	writer := &responseWriter{ResponseWriter: rep}
+	rep = writer
+
+	context := &Context{app: this, afterFilter: this.afterFilter}
 
 	context.Request = req
 	context.Response = rep
+	context.RequestId = requestId(req)
+	context.writer = writer
 
-	if this.Config.Session != nil {
+	rep.Header().Set("X-Request-Id", context.RequestId)
+
+	if this.Config.App != nil && this.Config.App.Access {
+		defer accessLog(context, writer, start)
+	}
+
+	httpInflight.Inc()
+	defer httpMetrics(context, writer, start)
+
+	if this.Config.App != nil && this.Config.App.Compress != 0 && req.Method != "HEAD" {
+		if encoding := compressEncoding(req.Header.Get("Accept-Encoding"), "gzip", "deflate"); encoding != "" {
+			compress := this.compressWriter(rep, encoding)
+			defer compress.Close()
+
+			rep = compress
+			context.Response = rep
+		}
+	}
+
+	defer this.serverError(context)
+
+	static := this.staticMount(req.URL.Path)
+
+	if this.Config.Session != nil && static == nil {
 		context.Session = sessionCreate(rep, req)
 	}
 
@@ -165,17 +239,56 @@ func (this *App) Handler(rep http.ResponseWriter, req *http.Request) {
 
 	context.Init()
 
-	for i := 0; i < len(this.beforeFilter); i++ {
+	if context.overflow {
+		context.Status(http.StatusRequestEntityTooLarge)
+		context.Json(Result{Code: http.StatusRequestEntityTooLarge, Msg: "request body too large limit:" + strconv.FormatInt(bodyLimit(), 10)})
+		context.Close()
+		return
+	}
+
+	if static != nil {
+		context.route = "static"
+		static.ServeHTTP(rep, req)
+		context.Close()
+		return
+	}
+
+	handler, params, allow := this.Router.find(context.Path, req.Method)
+
+	if handler == nil && allow == nil {
+		if uri, format := respondSuffix(context.Path); format != "" {
+			if fun, tmp, methods := this.Router.find(uri, req.Method); fun != nil || methods != nil {
+				handler, params, allow = fun, tmp, methods
+				context.format = format
+			}
+		}
+	}
+
+	context.PathParam = map[string]string{}
+	for key, value := range params {
+		context.PathParam[key] = value
+		context.Param[key] = value
+	}
+
+	for i := 0; i < len(this.beforeFilter) && !this.bare[strings.TrimRight(context.Path, "/")]; i++ {
 		if !this.beforeFilter[i](context) {
 			context.Close()
 			return
 		}
 	}
 
-	path := "/" + context.Module + "/" + context.Controller + "/" + context.Action
+	if handler == nil && allow != nil {
+		rep.Header().Set("Allow", strings.Join(allow, ", "))
 
-	handler := this.Router.find(path, req.Method)
-	if handler == nil {
+		if req.Method == "OPTIONS" {
+			rep.WriteHeader(http.StatusNoContent)
+		} else {
+			(*this.methodFunc)(context)
+		}
+	} else if handler == nil && this.static != nil && (req.Method == "GET" || req.Method == "HEAD") && this.static.resolve(req.URL.Path) != "" {
+		context.route = "static"
+		this.static.ServeHTTP(rep, req)
+	} else if handler == nil {
 		(*this.emptyFunc)(context)
 	} else {
 		handler(context)
@@ -184,6 +297,75 @@ func (this *App) Handler(rep http.ResponseWriter, req *http.Request) {
 	context.Close()
 }
 
+func (this *App) staticMount(path string) *staticHandler {
+	for _, static := range this.statics {
+		if strings.HasPrefix(path, static.prefix + "/") {
+			return static
+		}
+	}
+
+	return nil
+}
+
+func (this *App) compressWriter(rep http.ResponseWriter, encoding string) *compressWriter {
+	config := this.Config.App
+
+	min := config.CompressMin
+	if min <= 0 {
+		min = 1024
+	}
+
+	types := []string{}
+	for _, item := range strings.Split(config.CompressTypes, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			types = append(types, item)
+		}
+	}
+
+	if len(types) == 0 {
+		types = []string{"text/*", "application/json", "application/javascript", "application/xml", "image/svg+xml"}
+	}
+
+	return &compressWriter{ResponseWriter: rep, encoding: encoding, level: config.Compress, min: min, types: types}
+}
+
+var requestIdPattern = regexp.MustCompile(`^[\w\-\.:]+$`)
+
+func requestId(req *http.Request) string {
+	id := req.Header.Get("X-Request-Id")
+	if id != "" && len(id) <= 128 && requestIdPattern.MatchString(id) {
+		return id
+	}
+
+	return GetUUID()
+}
+
+func accessLog(ctx *Context, writer *responseWriter, start time.Time) {
+	var uid int64
+	if ctx.Current != nil {
+		uid = ctx.Current.Id
+	}
+
+	var text = strings.Builder{}
+	text.WriteString(ctx.RequestId)
+	text.WriteString(" ")
+	text.WriteString(ctx.Request.Method)
+	text.WriteString(" ")
+	text.WriteString(ctx.Request.URL.Path)
+	text.WriteString(" ")
+	text.WriteString(strconv.Itoa(writer.Status()))
+	text.WriteString(" ")
+	text.WriteString(strconv.FormatInt(writer.Size(), 10))
+	text.WriteString(" ")
+	text.WriteString(strconv.FormatFloat(float64(time.Since(start)) / float64(time.Millisecond), 'f', 3, 64))
+	text.WriteString("ms ")
+	text.WriteString(ctx.RealIP)
+	text.WriteString(" ")
+	text.WriteString(strconv.FormatInt(uid, 10))
+
+	Logger(text.String(), "access")
+}
+
 func (this *App) Run() {
 	this.init()
 
@@ -193,13 +375,32 @@ func (this *App) Run() {
 
 	http.HandleFunc("/", this.Handler)
 
-	if this.Config.App.Static != "" {
-		http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(this.Config.App.Static))))
+	for _, static := range staticMounts(this.Config) {
+		if static.prefix == "" {
+			this.static = static
+		} else {
+			this.statics = append(this.statics, static)
+		}
 	}
 
+	sort.Slice(this.statics, func(i, j int) bool {
+		return len(this.statics[i].prefix) > len(this.statics[j].prefix)
+	})
+
 	fmt.Println("app: " + this.Config.App.Host + ":" + strconv.Itoa(this.Config.App.Port))
 
-	this.srv = http.Server{Addr:this.Config.App.Host + ":" + strconv.Itoa(this.Config.App.Port)}
+	this.adminServe(this.Config.App.Host)
+
+	config := this.Config.App
+	if err := this.prepare(config.Host, config.Port, config.Cert, config.Key, config.Ca, config.Tls, config.Redirect); err != nil {
+		Logger("app.Run error:" + err.Error(), "error", "false")
+
+		if this.Config.App.Debug {
+			panic(err)
+		}
+
+		return
+	}
 
 	channel := make(chan os.Signal)
 	signal.Notify(channel, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGTERM)
@@ -213,9 +414,7 @@ func (this *App) Run() {
 		signWG.Done()
 	}()
 
-	err := this.srv.ListenAndServe()
-
-	signWG.Wait()
+	err := this.serve()
 
 	if err != nil && err != http.ErrServerClosed {
 		Logger("app.Run error:" + err.Error(), "error", "false")
@@ -223,26 +422,35 @@ func (this *App) Run() {
 		if this.Config.App.Debug {
 			panic(err)
 		}
+
+		return
 	}
+
+	signWG.Wait()
 }
 
 func (this *App) RunWS() {
 	this.init()
 
-	this.ws = ws.Server{Config:this.Config.WS}
-	this.ws.Events = map[string]interface{}{}
-	this.ws.Requests = map[int64]*ws.Request{}
-
-	for key, value := range this.events {
-		this.ws.Events[key] = value
-	}
+	server := this.websocket()
 
-	http.Handle(this.Config.WS.Path, &this.ws)
-	http.HandleFunc(this.Config.WS.Path + "/push", this.ws.HandlePUSH)
+	http.Handle(this.Config.WS.Path, server)
+	http.HandleFunc(this.Config.WS.Path + "/push", server.HandlePUSH)
 
 	fmt.Println("app: " + this.Config.WS.Host + ":" + strconv.Itoa(this.Config.WS.Port))
 
-	this.srv = http.Server{Addr:this.Config.WS.Host + ":" + strconv.Itoa(this.Config.WS.Port)}
+	this.adminServe(this.Config.WS.Host)
+
+	config := this.Config.WS
+	if err := this.prepare(config.Host, config.Port, config.Cert, config.Key, config.Ca, config.Tls, config.Redirect); err != nil {
+		Logger("app.RunWS error:" + err.Error(), "error", "false")
+
+		if this.Config.App != nil && this.Config.App.Debug {
+			panic(err)
+		}
+
+		return
+	}
 
 	channel := make(chan os.Signal)
 	signal.Notify(channel, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGTERM)
@@ -256,9 +464,7 @@ func (this *App) RunWS() {
 		signWG.Done()
 	}()
 
-	err := this.srv.ListenAndServe()
-
-	signWG.Wait()
+	err := this.serve()
 
 	if err != nil && err != http.ErrServerClosed {
 		Logger("app.RunWS error:" + err.Error(), "error", "false")
@@ -266,7 +472,25 @@ func (this *App) RunWS() {
 		if this.Config.App != nil && this.Config.App.Debug {
 			panic(err)
 		}
+
+		return
 	}
+
+	signWG.Wait()
+}
+
+func (this *App) websocket() *ws.Server {
+	this.wsOnce.Do(func() {
+		this.ws.Config = this.Config.WS
+		this.ws.Events = map[string]interface{}{}
+		this.ws.Requests = map[int64]*ws.Request{}
+
+		for key, value := range this.events {
+			this.ws.Events[key] = value
+		}
+	})
+
+	return &this.ws
 }
 
 func (this *App) gatewayPing(enable bool) {
@@ -279,10 +503,14 @@ func (this *App) gatewayPing(enable bool) {
 
 		go func(config *Config) {
 			pring := time.NewTicker(2 * time.Second)
+			defer pring.Stop()
+
 			for {
 				select {
 				case <- pring.C:
 					gatewayRequest(config, 1)
+				case <- this.done:
+					return
 				}
 			}
 		}(this.Config)
@@ -291,41 +519,99 @@ func (this *App) gatewayPing(enable bool) {
 	}
 }
 
+func (this *App) Shutdown(fun ShutdownFunc) {
+	this.shutdownFunc = append(this.shutdownFunc, fun)
+}
+
 func (this *App) Close(sign os.Signal) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2 * time.Second)
-	defer cancel()
+	timeout := 10 * time.Second
+	if this.Config.App != nil && this.Config.App.Shutdown > 0 {
+		timeout = time.Duration(this.Config.App.Shutdown) * time.Second
+	}
 
-	if this.Config.Redis != nil {
-		cache.Close()
+	if this.done != nil {
+		select {
+		case <- this.done:
+		default:
+			close(this.done)
+		}
 	}
 
-	if this.Config.MySQL != nil {
-		db.Close()
+	if this.Config.Gateway != nil && this.Config.Gateway.Enable {
+		this.gatewayPing(false)
 	}
 
-	if this.Config.Mongo != nil {
-		mongo.Close()
+	if this.Config.App != nil && this.Config.App.Drain > 0 {
+		time.Sleep(time.Duration(this.Config.App.Drain) * time.Second)
 	}
 
-	if this.Config.MQ != nil {
-		mq.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		err := this.srv.Shutdown(ctx)
+		if err != nil {
+			Logger("app.server shutdown error:" + err.Error(), "error", "false")
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		this.ws.Shutdown(ctx)
+	}()
+
+	wg.Wait()
+
+	if this.redirect != nil {
+		this.redirect.Shutdown(ctx)
+	}
+
+	if this.admin != nil {
+		this.admin.Shutdown(ctx)
+	}
+
+	for _, fun := range this.shutdownFunc {
+		func() {
+			defer this.recover(nil)
+			fun(ctx)
+		}()
 	}
 
 	if this.Config.Cron != nil {
-		cron.Stop()
+		select {
+		case <- cron.Stop().Done():
+		case <- ctx.Done():
+			Logger("app.cron shutdown timeout", "error", "false")
+		}
+	}
+
+	if this.Config.MQ != nil {
+		mq.Stop(ctx)
 	}
 
 	if this.Config.Session != nil {
 		sessionGC()
 	}
 
-	if this.Config.Gateway != nil && this.Config.Gateway.Enable {
-		this.gatewayPing(false)
+	if this.Config.Redis != nil {
+		cache.Close()
 	}
 
-	err := this.srv.Shutdown(ctx)
-	if err != nil {
-		Logger("app.server shutdown error:" + err.Error(), "error", "false")
+	if this.Config.MySQL != nil {
+		db.Close()
+	}
+
+	if this.Config.Mongo != nil {
+		mongo.Close()
+	}
+
+	if this.Config.MQ != nil {
+		mq.Close()
 	}
 
 	fmt.Println("app close of " + sign.String())
@@ -365,11 +651,15 @@ func New() *App {
 	app.Config.App = &configOfApp{}
 
 	app.Router = &Router{}
-	app.Router.data = map[string]map[string]Handler{}
+	app.Router.init()
+
+	app.templates = newTemplateManager(&app)
 
 	app.beforeFilter = []BeforeFilterFunc{}
 	app.afterFilter = []AfterFilterFunc{}
 	app.events = map[string]interface{}{}
+	app.shutdownFunc = []ShutdownFunc{}
+	app.done = make(chan struct{})
 
 	return &app
 }
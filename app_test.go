@@ -0,0 +1,24 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	for id, keep := range map[string]bool{"abc-123.x:y": true, "bad id": false, strings.Repeat("a", 129): false} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-Id", id)
+
+		rep := httptest.NewRecorder()
+		app.Handler(rep, req)
+
+		if got := rep.Header().Get("X-Request-Id"); (got == id) != keep || got == "" {
+			t.Errorf("%q: keep %v, got %q", id, keep, got)
+		}
+	}
+}
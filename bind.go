@@ -0,0 +1,609 @@
+package tec
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type ValidateError struct {
+	Field string `json:"field"`
+	Rule string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidateFunc func(data string, param string) bool
+
+var validateRules = map[string]ValidateFunc{
+	"email": func(data string, param string) bool { return IsEmail(data) },
+	"mobile": func(data string, param string) bool { return IsMobile(data) },
+	"phone": func(data string, param string) bool { return IsPhone(data) },
+	"cint": func(data string, param string) bool { return IsCint(data) },
+	"number": func(data string, param string) bool { return IsCNumber(data) },
+	"ip": func(data string, param string) bool { return IsIP(data) },
+	"ansi": func(data string, param string) bool { return IsAnsi(data) },
+	"date": func(data string, param string) bool { return IsShortdate(data) },
+	"datetime": func(data string, param string) bool { return IsTimeStamp(data) },
+}
+
+var validateMessages = map[string]map[string]string{
+	"en": {
+		"invalid": "{field} is invalid",
+		"type": "{field} has an invalid value",
+		"required": "{field} is required",
+		"email": "{field} must be a valid email address",
+		"mobile": "{field} must be a valid mobile number",
+		"phone": "{field} must be a valid phone number",
+		"cint": "{field} must be an integer",
+		"number": "{field} must be a number",
+		"ip": "{field} must be a valid IP address",
+		"ansi": "{field} may only contain letters, digits, _ and .",
+		"date": "{field} must be a date like 2006-01-02",
+		"datetime": "{field} must be a time like 2006-01-02 15:04:05",
+		"min": "{field} must be at least {param}",
+		"max": "{field} must be at most {param}",
+		"range": "{field} must be between {min} and {max}",
+		"minlen": "{field} length must be at least {param}",
+		"maxlen": "{field} length must be at most {param}",
+		"len": "{field} length must be {param}",
+		"lenrange": "{field} length must be between {min} and {max}",
+		"regex": "{field} has an invalid format",
+		"in": "{field} must be one of {param}",
+	},
+	"zh": {
+		"invalid": "{field}不正确",
+		"type": "{field}的值类型不正确",
+		"required": "{field}不能为空",
+		"email": "{field}必须是有效的邮箱地址",
+		"mobile": "{field}必须是有效的手机号码",
+		"phone": "{field}必须是有效的电话号码",
+		"cint": "{field}必须是整数",
+		"number": "{field}必须是数字",
+		"ip": "{field}必须是有效的IP地址",
+		"ansi": "{field}只能包含字母、数字、_和.",
+		"date": "{field}必须是 2006-01-02 格式的日期",
+		"datetime": "{field}必须是 2006-01-02 15:04:05 格式的时间",
+		"min": "{field}不能小于{param}",
+		"max": "{field}不能大于{param}",
+		"range": "{field}必须在{min}到{max}之间",
+		"minlen": "{field}长度不能少于{param}",
+		"maxlen": "{field}长度不能超过{param}",
+		"len": "{field}长度必须为{param}",
+		"lenrange": "{field}长度必须在{min}到{max}之间",
+		"regex": "{field}格式不正确",
+		"in": "{field}必须是{param}之一",
+	},
+}
+
+var validateRegexps sync.Map
+var validateMutex sync.RWMutex
+
+func ValidateRule(name string, fun ValidateFunc) {
+	validateMutex.Lock()
+	defer validateMutex.Unlock()
+
+	validateRules[name] = fun
+}
+
+func ValidateMessage(lang string, rule string, message string) {
+	validateMutex.Lock()
+	defer validateMutex.Unlock()
+
+	if validateMessages[lang] == nil {
+		validateMessages[lang] = map[string]string{}
+	}
+
+	validateMessages[lang][rule] = message
+}
+
+func validateMessage(lang string, rule string, label string, param string) string {
+	validateMutex.RLock()
+	message, ok := validateMessages[lang][rule]
+	if !ok {
+		message, ok = validateMessages["en"][rule]
+	}
+
+	if !ok {
+		message = validateMessages["en"]["invalid"]
+	}
+	validateMutex.RUnlock()
+
+	min, max := param, param
+	if strings.Contains(param, ":") {
+		tmp := strings.SplitN(param, ":", 2)
+		min, max = tmp[0], tmp[1]
+	}
+
+	return strings.NewReplacer("{field}", label, "{param}", strings.Replace(param, "|", ", ", -1), "{min}", min, "{max}", max).Replace(message)
+}
+
+func validateRegexp(pattern string) *regexp.Regexp {
+	if tmp, ok := validateRegexps.Load(pattern); ok {
+		return tmp.(*regexp.Regexp)
+	}
+
+	tmp, err := regexp.Compile(pattern)
+	if err != nil {
+		Logger("validate regex invalid:" + pattern, "error")
+		return nil
+	}
+
+	validateRegexps.Store(pattern, tmp)
+
+	return tmp
+}
+
+func validateSplit(tag string) [][]string {
+	rules := [][]string{}
+
+	for tag != "" {
+		var item string
+
+		if strings.HasPrefix(tag, "regex=") {
+			item, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			item, tag = tag[0:i], tag[i + 1:]
+		} else {
+			item, tag = tag, ""
+		}
+
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if i := strings.Index(item, "="); i >= 0 {
+			rules = append(rules, []string{item[0:i], item[i + 1:]})
+		} else {
+			rules = append(rules, []string{item, ""})
+		}
+	}
+
+	return rules
+}
+
+func validateName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		if name := strings.Split(field.Tag.Get(key), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func validateNumber(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+
+	return 0, false
+}
+
+func validateLength(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	}
+
+	return 0, false
+}
+
+func validateField(value reflect.Value, tag string) (string, string) {
+	rules := validateSplit(tag)
+
+	empty := value.IsZero()
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		if rule[0] == "required" && (empty || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "")) {
+			return "required", ""
+		}
+	}
+
+	if empty {
+		return "", ""
+	}
+
+	for _, rule := range rules {
+		name, param := rule[0], rule[1]
+
+		switch name {
+		case "required":
+		case "min", "max":
+			limit, _ := strconv.ParseFloat(param, 64)
+
+			if num, ok := validateNumber(value); ok {
+				if (name == "min" && num < limit) || (name == "max" && num > limit) {
+					return name, param
+				}
+			} else if length, ok := validateLength(value); ok {
+				if (name == "min" && float64(length) < limit) || (name == "max" && float64(length) > limit) {
+					return name + "len", param
+				}
+			}
+		case "len":
+			length, _ := validateLength(value)
+
+			if strings.Contains(param, ":") {
+				tmp := strings.SplitN(param, ":", 2)
+				if length < FormatInt(tmp[0]) || (tmp[1] != "" && length > FormatInt(tmp[1])) {
+					return "lenrange", param
+				}
+			} else if length != FormatInt(param) {
+				return "len", param
+			}
+		case "range":
+			tmp := strings.SplitN(param + ":", ":", 3)
+			num, ok := validateNumber(value)
+			if !ok {
+				num = FormatFloat64(fmt.Sprint(value.Interface()))
+			}
+
+			if num < FormatFloat64(tmp[0]) || (tmp[1] != "" && num > FormatFloat64(tmp[1])) {
+				return "range", tmp[0] + ":" + tmp[1]
+			}
+		case "regex":
+			pattern := validateRegexp(param)
+			if pattern == nil || !pattern.MatchString(fmt.Sprint(value.Interface())) {
+				return "regex", param
+			}
+		case "in":
+			if !InArray(fmt.Sprint(value.Interface()), strings.Split(param, "|")) {
+				return "in", param
+			}
+		default:
+			validateMutex.RLock()
+			fun, ok := validateRules[name]
+			validateMutex.RUnlock()
+
+			if !ok {
+				Logger("validate rule not found:" + name, "error")
+				continue
+			}
+
+			if value.Kind() == reflect.Slice {
+				for i := 0; i < value.Len(); i++ {
+					if !fun(fmt.Sprint(value.Index(i).Interface()), param) {
+						return name, param
+					}
+				}
+			} else if !fun(fmt.Sprint(value.Interface()), param) {
+				return name, param
+			}
+		}
+	}
+
+	return "", ""
+}
+
+func validateStruct(value reflect.Value, prefix string, lang string, errors *[]ValidateError) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	kind := value.Type()
+
+	for i := 0; i < kind.NumField(); i++ {
+		field := kind.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		item := value.Field(i)
+
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Tag.Get("form") == "" {
+			validateStruct(item, prefix, lang, errors)
+			continue
+		}
+
+		name := prefix + validateName(field)
+
+		elem := item
+		for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+
+		if elem.Kind() == reflect.Struct {
+			validateStruct(elem, name + ".", lang, errors)
+		} else if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			for j := 0; j < elem.Len(); j++ {
+				validateStruct(elem.Index(j), name + "." + strconv.Itoa(j) + ".", lang, errors)
+			}
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		rule, param := validateField(item, tag)
+		if rule == "" {
+			continue
+		}
+
+		label := field.Tag.Get("label")
+		if label == "" {
+			label = name
+		}
+
+		*errors = append(*errors, ValidateError{Field: name, Rule: rule, Message: validateMessage(lang, rule, label, param)})
+	}
+}
+
+func Validate(value interface{}, lang string) []ValidateError {
+	errors := []ValidateError{}
+	validateStruct(reflect.ValueOf(value), "", lang, &errors)
+
+	return errors
+}
+
+func bindScalar(value reflect.Value, data string) bool {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(data)
+	case reflect.Bool:
+		if data == "" {
+			return true
+		}
+
+		if data == "on" {
+			data = "true"
+		}
+
+		tmp, err := strconv.ParseBool(data)
+		if err != nil {
+			return false
+		}
+
+		value.SetBool(tmp)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if data == "" {
+			return true
+		}
+
+		tmp, err := strconv.ParseInt(data, 10, value.Type().Bits())
+		if err != nil {
+			return false
+		}
+
+		value.SetInt(tmp)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if data == "" {
+			return true
+		}
+
+		tmp, err := strconv.ParseUint(data, 10, value.Type().Bits())
+		if err != nil {
+			return false
+		}
+
+		value.SetUint(tmp)
+	case reflect.Float32, reflect.Float64:
+		if data == "" {
+			return true
+		}
+
+		tmp, err := strconv.ParseFloat(data, value.Type().Bits())
+		if err != nil {
+			return false
+		}
+
+		value.SetFloat(tmp)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func bindValue(value reflect.Value, data []string) bool {
+	if value.Kind() == reflect.Ptr {
+		tmp := reflect.New(value.Type().Elem())
+		if !bindValue(tmp.Elem(), data) {
+			return false
+		}
+
+		value.Set(tmp)
+
+		return true
+	}
+
+	if value.Kind() == reflect.Slice {
+		if len(data) == 1 && strings.Contains(data[0], ",") {
+			data = strings.Split(data[0], ",")
+		}
+
+		tmp := reflect.MakeSlice(value.Type(), len(data), len(data))
+		for i, item := range data {
+			if !bindScalar(tmp.Index(i), strings.TrimSpace(item)) {
+				return false
+			}
+		}
+
+		value.Set(tmp)
+
+		return true
+	}
+
+	return bindScalar(value, data[0])
+}
+
+var bindFileType = reflect.TypeOf(&multipart.FileHeader{})
+var bindFilesType = reflect.TypeOf([]*multipart.FileHeader{})
+
+func bindStruct(value reflect.Value, prefix string, lookup func(name string) []string, files map[string][]*multipart.FileHeader, errors *[]ValidateError) {
+	kind := value.Type()
+
+	for i := 0; i < kind.NumField(); i++ {
+		field := kind.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		item := value.Field(i)
+
+		if field.Anonymous && field.Tag.Get("form") == "" && field.Tag.Get("json") == "" && item.Kind() == reflect.Struct {
+			bindStruct(item, prefix, lookup, files, errors)
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = validateName(field)
+		}
+
+		if field.Type == bindFileType || field.Type == bindFilesType {
+			if headers, ok := files[name]; ok && len(headers) > 0 {
+				if field.Type == bindFileType {
+					item.Set(reflect.ValueOf(headers[0]))
+				} else {
+					item.Set(reflect.ValueOf(headers))
+				}
+			}
+
+			continue
+		}
+
+		data := lookup(name)
+		if data == nil {
+			continue
+		}
+
+		if !bindValue(item, data) {
+			*errors = append(*errors, ValidateError{Field: prefix + name, Rule: "type"})
+		}
+	}
+}
+
+func (this *Context) lang() string {
+	for _, item := range strings.Split(this.Header["Accept-Language"], ",") {
+		lang := strings.ToLower(strings.TrimSpace(strings.Split(item, ";")[0]))
+		if lang == "" {
+			continue
+		}
+
+		validateMutex.RLock()
+		_, ok := validateMessages[lang]
+		if !ok && strings.Contains(lang, "-") {
+			lang = strings.Split(lang, "-")[0]
+			_, ok = validateMessages[lang]
+		}
+		validateMutex.RUnlock()
+
+		if ok {
+			return lang
+		}
+	}
+
+	if CONFIG != nil && CONFIG.App != nil && CONFIG.App.Lang != "" {
+		return CONFIG.App.Lang
+	}
+
+	return "en"
+}
+
+func (this *Context) Bind(value interface{}) bool {
+	target := reflect.ValueOf(value)
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		panic("context.Bind requires a pointer to struct")
+	}
+
+	errors := []ValidateError{}
+	lang := this.lang()
+
+	query := this.Request.URL.Query()
+	form := this.Request.PostForm
+	if this.Request.MultipartForm != nil {
+		form = this.Request.MultipartForm.Value
+	}
+
+	bindStruct(target.Elem(), "", func(name string) []string {
+		if data, ok := form[name]; ok {
+			return data
+		}
+
+		if data, ok := query[name]; ok {
+			return data
+		}
+
+		return nil
+	}, this.Files, &errors)
+
+	if this.BodyError() != nil {
+		this.Status(http.StatusRequestEntityTooLarge)
+		this.Json(Result{Code: http.StatusRequestEntityTooLarge, Msg: "request body too large limit:" + strconv.FormatInt(bodyLimit(), 10)})
+		return false
+	}
+
+	if strings.Contains(this.Header["Content-Type"], "application/json") && len(this.Body()) > 0 {
+		if err := json.Unmarshal(this.Body(), value); err != nil {
+			this.Status(http.StatusBadRequest)
+			this.Json(Result{Code: http.StatusBadRequest, Msg: "invalid json body:" + err.Error()})
+			return false
+		}
+	}
+
+	bindStruct(target.Elem(), "", func(name string) []string {
+		if data, ok := this.PathParam[name]; ok {
+			return []string{data}
+		}
+
+		return nil
+	}, nil, &errors)
+
+	for i := 0; i < len(errors); i++ {
+		errors[i].Message = validateMessage(lang, "type", errors[i].Field, "")
+	}
+
+	for _, item := range Validate(value, lang) {
+		exist := false
+		for _, tmp := range errors {
+			if tmp.Field == item.Field {
+				exist = true
+				break
+			}
+		}
+
+		if !exist {
+			errors = append(errors, item)
+		}
+	}
+
+	if len(errors) == 0 {
+		return true
+	}
+
+	this.Status(http.StatusUnprocessableEntity)
+	this.Json(Result{Code: http.StatusUnprocessableEntity, Msg: errors[0].Message, Data: errors})
+
+	return false
+}
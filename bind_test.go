@@ -0,0 +1,86 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type bindItem struct {
+	Sku string `json:"sku" validate:"required"`
+}
+
+type bindOrder struct {
+	Name string `json:"name" label:"姓名" validate:"required,len=2:4"`
+	Age int `json:"age" validate:"range=18:60"`
+	Email string `json:"email" validate:"email"`
+	Code string `json:"code" validate:"regex=^[a-z]{2,3}$"`
+	Status string `json:"status" validate:"in=paid|closed"`
+	Items []bindItem `json:"items" validate:"required,min=1"`
+}
+
+func TestValidate(t *testing.T) {
+	order := bindOrder{Name: "张三", Age: 30, Email: "a@b.com", Code: "ab", Status: "paid", Items: []bindItem{{Sku: "x"}}}
+	if errors := Validate(&order, "en"); len(errors) != 0 {
+		t.Fatalf("expect valid order, got %+v", errors)
+	}
+
+	order = bindOrder{Name: "张", Age: 70, Email: "bad", Code: "abcd", Status: "new", Items: []bindItem{{}}}
+
+	rules := map[string]string{}
+	messages := map[string]string{}
+	for _, item := range Validate(&order, "zh") {
+		rules[item.Field] = item.Rule
+		messages[item.Field] = item.Message
+	}
+
+	expect := map[string]string{"name": "lenrange", "age": "range", "email": "email", "code": "regex", "status": "in", "items.0.sku": "required"}
+	if !reflect.DeepEqual(rules, expect) {
+		t.Errorf("expect %v, got %v", expect, rules)
+	}
+
+	if messages["name"] != "姓名长度必须在2到4之间" {
+		t.Errorf("expect labelled message, got %q", messages["name"])
+	}
+}
+
+func TestBindQueryType(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.GET("/orders/:id", func(ctx *Context) {
+		req := struct {
+			Id int64 `json:"id"`
+			Page int `form:"page" validate:"min=1"`
+		}{}
+
+		if ctx.Bind(&req) {
+			ctx.Json(req)
+		}
+	})
+
+	cases := map[string]string{
+		"/orders/7?page=2": `{"id":7,"Page":2}`,
+		"/orders/7?page=x": `{"code":422,"msg":"page的值类型不正确","data":[{"field":"page","rule":"type","message":"page的值类型不正确"}]}`,
+		"/orders/7?page=-1": `{"code":422,"msg":"page不能小于1","data":[{"field":"page","rule":"min","message":"page不能小于1"}]}`,
+	}
+
+	for path, body := range cases {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+
+		rep := httptest.NewRecorder()
+		app.Handler(rep, req)
+
+		if rep.Body.String() != body {
+			t.Errorf("%s: expect %s, got %s", path, body, rep.Body.String())
+		}
+	}
+}
+
+func TestValidateSplit(t *testing.T) {
+	expect := [][]string{{"required", ""}, {"regex", "^[a-z]{2,3}$"}}
+	if rules := validateSplit("required,regex=^[a-z]{2,3}$"); !reflect.DeepEqual(rules, expect) {
+		t.Errorf("expect %v, got %v", expect, rules)
+	}
+}
@@ -0,0 +1,201 @@
+package tec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+var ErrBodyTooLarge = errors.New("request body too large")
+
+type bodyOverflow struct{}
+
+func (this bodyOverflow) Read(data []byte) (int, error) {
+	return 0, ErrBodyTooLarge
+}
+
+func bodyLimit() int64 {
+	if CONFIG != nil && CONFIG.App != nil && CONFIG.App.BodyLimit > 0 {
+		return CONFIG.App.BodyLimit
+	}
+
+	return 8 << 20
+}
+
+func jsonNormalize(value interface{}) interface{} {
+	switch value.(type) {
+	case json.Number:
+		if num, err := value.(json.Number).Int64(); err == nil {
+			return num
+		}
+
+		num, _ := value.(json.Number).Float64()
+		return num
+	case map[string]interface{}:
+		for key, item := range value.(map[string]interface{}) {
+			value.(map[string]interface{})[key] = jsonNormalize(item)
+		}
+	case []interface{}:
+		for i, item := range value.([]interface{}) {
+			value.([]interface{})[i] = jsonNormalize(item)
+		}
+	}
+
+	return value
+}
+
+func (this *Context) readBody() {
+	if this.body != nil || this.Request == nil || this.Request.Body == nil {
+		return
+	}
+
+	limit := bodyLimit()
+
+	body, err := ioutil.ReadAll(io.LimitReader(this.Request.Body, limit + 1))
+	if err != nil {
+		this.Logger("context.readBody error:" + err.Error(), "error")
+	}
+
+	if int64(len(body)) > limit {
+		this.overflow = true
+		this.body = []byte{}
+		this.Request.Body = ioutil.NopCloser(bodyOverflow{})
+		return
+	}
+
+	this.body = body
+	this.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+}
+
+func (this *Context) BodyError() error {
+	this.readBody()
+
+	if this.overflow {
+		return ErrBodyTooLarge
+	}
+
+	return nil
+}
+
+func (this *Context) Body() []byte {
+	this.readBody()
+
+	return this.body
+}
+
+func (this *Context) JsonValue(path string) interface{} {
+	if this.data == nil {
+		decoder := json.NewDecoder(bytes.NewReader(this.Body()))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&this.data); err != nil {
+			return nil
+		}
+
+		this.data = jsonNormalize(this.data)
+	}
+
+	value := this.data
+	if path == "" {
+		return value
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch value.(type) {
+		case map[string]interface{}:
+			tmp, ok := value.(map[string]interface{})[key]
+			if !ok {
+				return nil
+			}
+
+			value = tmp
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(value.([]interface{})) {
+				return nil
+			}
+
+			value = value.([]interface{})[index]
+		default:
+			return nil
+		}
+	}
+
+	return value
+}
+
+func (this *Context) JsonString(path string) string {
+	return jsonString(this.JsonValue(path))
+}
+
+func jsonString(data interface{}) string {
+	switch value := data.(type) {
+	case string:
+		return value
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+
+	return ""
+}
+
+func (this *Context) JsonInt64(path string) int64 {
+	switch value := this.JsonValue(path).(type) {
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	case string:
+		return FormatInt64(value)
+	}
+
+	return 0
+}
+
+func (this *Context) JsonFloat64(path string) float64 {
+	switch value := this.JsonValue(path).(type) {
+	case int64:
+		return float64(value)
+	case float64:
+		return value
+	case string:
+		return FormatFloat64(value)
+	}
+
+	return 0
+}
+
+func (this *Context) JsonBool(path string) bool {
+	switch value := this.JsonValue(path).(type) {
+	case bool:
+		return value
+	case string:
+		return FormatBool(value)
+	}
+
+	return false
+}
+
+func (this *Context) JsonArray(path string) []interface{} {
+	if value, ok := this.JsonValue(path).([]interface{}); ok {
+		return value
+	}
+
+	return nil
+}
+
+func (this *Context) JsonObject(path string) map[string]interface{} {
+	if value, ok := this.JsonValue(path).(map[string]interface{}); ok {
+		return value
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package tec
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyDottedKeyAndLimit(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.POST("/json", func(ctx *Context) {
+		ctx.Text(ctx.Param["a.b"] + "," + ctx.Param["n"])
+	})
+
+	app.Router.POST("/raw", func(ctx *Context) {
+		body := ctx.Body()
+		raw, err := ioutil.ReadAll(ctx.Request.Body)
+		if ctx.BodyError() == ErrBodyTooLarge && err == ErrBodyTooLarge && len(body) == 0 && len(raw) == 0 {
+			ctx.Text("too large")
+			return
+		}
+
+		ctx.Text(string(raw))
+	})
+
+	req := httptest.NewRequest("POST", "/json", strings.NewReader(`{"a.b":"x","n":1.5}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rep := httptest.NewRecorder()
+	app.Handler(rep, req)
+
+	if rep.Body.String() != "x,1.5" {
+		t.Errorf("expect dotted key in Param, got %q", rep.Body.String())
+	}
+
+	app.Config.App.BodyLimit = 4
+
+	req = httptest.NewRequest("POST", "/json", strings.NewReader(`{"a":"xxxxx"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rep = httptest.NewRecorder()
+	app.Handler(rep, req)
+
+	if rep.Code != 413 {
+		t.Errorf("expect 413 for large json, got %d", rep.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/raw", strings.NewReader("123456"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	rep = httptest.NewRecorder()
+	app.Handler(rep, req)
+
+	if rep.Body.String() != "too large" {
+		t.Errorf("expect overflow error for raw body, got %q", rep.Body.String())
+	}
+}
@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/agilecho/tec/cache/redis"
+	"github.com/agilecho/tec/metrics"
 	"os"
 	"strconv"
 	"strings"
@@ -85,12 +88,37 @@ func (this *Cache) logger(message string) {
 	}(text.String(), this)
 }
 
+func (this *Cache) Stats() redis.PoolStats {
+	if this.pool == nil {
+		return redis.PoolStats{}
+	}
+
+	return this.pool.Stats()
+}
+
 func (this *Cache) Close() {
 	if this.pool != nil {
 		this.pool.Close()
 	}
 }
 
+func (this *Cache) Check(ctx context.Context) error {
+	if this.pool == nil {
+		return errors.New("cache pool not open")
+	}
+
+	conn, err := this.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	_, err = conn.Do("PING")
+
+	return err
+}
+
 func (this *Cache) Do(command string, args ...interface{}) (interface{}, error) {
 	if this.pool == nil {
 		return nil, nil
@@ -120,6 +148,34 @@ func (this *Cache) Do(command string, args ...interface{}) (interface{}, error)
 	return result, nil
 }
 
+func (this *Cache) Eval(script string, keys []string, args ...interface{}) []string {
+	if this.pool == nil {
+		return nil
+	}
+
+	conn := this.pool.Get()
+	if conn == nil {
+		return nil
+	}
+
+	defer conn.Close()
+
+	params := []interface{}{script, len(keys)}
+	for _, key := range keys {
+		params = append(params, this.config.Prefix + key)
+	}
+
+	params = append(params, args...)
+
+	result, err := conn.Do("EVAL", params...)
+	if err != nil {
+		this.logger("cache.Eval error:" + err.Error())
+		return nil
+	}
+
+	return redis.Strings(result, nil)
+}
+
 func (this *Cache) Has(key string) int {
 	return redis.Int(this.Do("EXISTS", key))
 }
@@ -326,12 +382,40 @@ var handler *Cache
 
 func Init(config *Config) {
 	handler = New(config)
+
+	metrics.NewGaugeFunc("redis_pool_active_connections", "Number of connections in the redis pool.", func() float64 {
+		return float64(handler.Stats().ActiveCount)
+	})
+
+	metrics.NewGaugeFunc("redis_pool_idle_connections", "Number of idle connections in the redis pool.", func() float64 {
+		return float64(handler.Stats().IdleCount)
+	})
+
+	metrics.NewCounterFunc("redis_pool_wait_count_total", "Total number of connections waited for.", func() float64 {
+		return float64(handler.Stats().WaitCount)
+	})
+
+	metrics.NewCounterFunc("redis_pool_wait_seconds_total", "Total time blocked waiting for a new connection.", func() float64 {
+		return handler.Stats().WaitDuration.Seconds()
+	})
+}
+
+func Stats() redis.PoolStats {
+	return handler.Stats()
+}
+
+func Check(ctx context.Context) error {
+	return handler.Check(ctx)
 }
 
 func Close() {
 	handler.Close()
 }
 
+func Eval(script string, keys []string, args ...interface{}) []string {
+	return handler.Eval(script, keys, args...)
+}
+
 func Has(key string) int {
 	return handler.Has(key)
 }
@@ -0,0 +1,261 @@
+package tec
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var compressPools sync.Map
+
+func compressPool(encoding string, level int) *sync.Pool {
+	key := encoding + strconv.Itoa(level)
+
+	if pool, ok := compressPools.Load(key); ok {
+		return pool.(*sync.Pool)
+	}
+
+	pool, _ := compressPools.LoadOrStore(key, &sync.Pool{
+		New: func() interface{} {
+			if encoding == "gzip" {
+				writer, err := gzip.NewWriterLevel(nil, level)
+				if err != nil {
+					writer = gzip.NewWriter(nil)
+				}
+
+				return writer
+			}
+
+			writer, err := flate.NewWriter(nil, level)
+			if err != nil {
+				writer, _ = flate.NewWriter(nil, flate.DefaultCompression)
+			}
+
+			return writer
+		},
+	})
+
+	return pool.(*sync.Pool)
+}
+
+func compressEncoding(accept string, offers ...string) string {
+	values := map[string]float64{}
+
+	for _, item := range strings.Split(accept, ",") {
+		fields := strings.Split(strings.TrimSpace(item), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, field := range fields[1:] {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if strings.HasPrefix(field, "q=") {
+				q, _ = strconv.ParseFloat(strings.TrimSpace(field[2:]), 64)
+			}
+		}
+
+		values[name] = q
+	}
+
+	result, best := "", 0.0
+
+	for _, offer := range offers {
+		q, ok := values[offer]
+		if !ok {
+			q = values["*"]
+		}
+
+		if q > best {
+			result, best = offer, q
+		}
+	}
+
+	return result
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding string
+	level int
+	min int
+	types []string
+
+	status int
+	buffer []byte
+	decided bool
+	disabled bool
+	writer io.WriteCloser
+}
+
+func (this *compressWriter) allowed() bool {
+	header := this.ResponseWriter.Header()
+
+	if this.disabled || header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	if this.status == http.StatusNoContent || this.status == http.StatusNotModified || this.status == http.StatusPartialContent || (this.status > 0 && this.status < 200) {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(this.buffer)
+	}
+
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	for _, item := range this.types {
+		if item == contentType || (strings.HasSuffix(item, "/*") && strings.HasPrefix(contentType, item[0:len(item) - 1])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *compressWriter) decide(compress bool) {
+	this.decided = true
+
+	if compress && this.allowed() {
+		header := this.ResponseWriter.Header()
+		header.Set("Content-Encoding", this.encoding)
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/" + etag)
+		}
+
+		pool := compressPool(this.encoding, this.level)
+
+		if this.encoding == "gzip" {
+			writer := pool.Get().(*gzip.Writer)
+			writer.Reset(this.ResponseWriter)
+			this.writer = writer
+		} else {
+			writer := pool.Get().(*flate.Writer)
+			writer.Reset(this.ResponseWriter)
+			this.writer = writer
+		}
+	}
+
+	if this.status > 0 {
+		this.ResponseWriter.WriteHeader(this.status)
+	}
+
+	if len(this.buffer) > 0 {
+		buffer := this.buffer
+		this.buffer = nil
+		this.write(buffer)
+	}
+}
+
+func (this *compressWriter) write(data []byte) (int, error) {
+	if this.writer != nil {
+		return this.writer.Write(data)
+	}
+
+	return this.ResponseWriter.Write(data)
+}
+
+func (this *compressWriter) WriteHeader(code int) {
+	if this.decided {
+		this.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if this.status == 0 {
+		this.status = code
+	}
+}
+
+func (this *compressWriter) Write(data []byte) (int, error) {
+	if this.decided {
+		return this.write(data)
+	}
+
+	this.buffer = append(this.buffer, data...)
+
+	if len(this.buffer) >= this.min {
+		this.decide(true)
+	}
+
+	return len(data), nil
+}
+
+func (this *compressWriter) Flush() {
+	if !this.decided {
+		this.decide(len(this.buffer) > 0)
+	}
+
+	if flusher, ok := this.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+
+	if flusher, ok := this.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (this *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := this.ResponseWriter.(http.Hijacker); ok {
+		this.decided = true
+		this.disabled = true
+
+		return hijacker.Hijack()
+	}
+
+	return nil, nil, errors.New("response does not implement http.Hijacker")
+}
+
+func (this *compressWriter) Unwrap() http.ResponseWriter {
+	return this.ResponseWriter
+}
+
+func (this *compressWriter) Disable() {
+	if !this.decided {
+		this.disabled = true
+	}
+}
+
+func (this *compressWriter) Reset() bool {
+	if this.decided {
+		return false
+	}
+
+	this.status = 0
+	this.buffer = nil
+
+	header := this.ResponseWriter.Header()
+	for _, name := range []string{"Content-Type", "Content-Length", "Content-Disposition", "ETag", "Last-Modified"} {
+		header.Del(name)
+	}
+
+	return true
+}
+
+func (this *compressWriter) Close() {
+	if !this.decided {
+		this.decide(len(this.buffer) >= this.min)
+	}
+
+	if this.writer == nil {
+		return
+	}
+
+	this.writer.Close()
+
+	compressPool(this.encoding, this.level).Put(this.writer)
+	this.writer = nil
+}
@@ -0,0 +1,113 @@
+package tec
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressPanicDiscardsBuffer(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+	app.Config.App.Compress = 6
+
+	app.Router.GET("/panic", func(ctx *Context) {
+		ctx.Response.Header().Set("Content-Type", "text/html")
+		ctx.Response.Write([]byte("<p>partial"))
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rep := httptest.NewRecorder()
+	app.Handler(rep, req)
+
+	if rep.Code != 500 || strings.Contains(rep.Body.String(), "partial") || !strings.HasPrefix(rep.Body.String(), "{") {
+		t.Errorf("expect clean 500 json, got %d %q", rep.Code, rep.Body.String())
+	}
+}
+
+func TestCompressEncoding(t *testing.T) {
+	cases := []struct {
+		accept string
+		offers []string
+		expect string
+	}{
+		{"gzip, deflate", []string{"gzip", "deflate"}, "gzip"},
+		{"gzip;q=0.5, deflate", []string{"gzip", "deflate"}, "deflate"},
+		{"gzip;q=0, *", []string{"gzip", "deflate"}, "deflate"},
+		{"identity", []string{"gzip", "deflate"}, ""},
+		{"br;q=0, gzip", []string{"br", "gzip"}, "gzip"},
+		{"xbr, gzip;q=0.8", []string{"br", "gzip"}, "gzip"},
+		{"br, gzip", []string{"br", "gzip"}, "br"},
+		{"br, gzip", []string{"gzip"}, "gzip"},
+		{"br", nil, ""},
+	}
+
+	for _, item := range cases {
+		if encoding := compressEncoding(item.accept, item.offers...); encoding != item.expect {
+			t.Errorf("%q %v: expect %q, got %q", item.accept, item.offers, item.expect, encoding)
+		}
+	}
+}
+
+func TestCompressWriter(t *testing.T) {
+	large := strings.Repeat("hello compress ", 100)
+
+	cases := []struct {
+		name string
+		encoding string
+		contentType string
+		preset string
+		status int
+		body string
+		compressed bool
+	}{
+		{"gzip text", "gzip", "text/html", "", 200, large, true},
+		{"deflate json", "deflate", "application/json", "", 200, large, true},
+		{"below min", "gzip", "text/html", "", 200, "small", false},
+		{"image type", "gzip", "image/png", "", 200, large, false},
+		{"already encoded", "gzip", "text/html", "br", 200, large, false},
+		{"not modified", "gzip", "text/html", "", 304, "", false},
+	}
+
+	for _, item := range cases {
+		rep := httptest.NewRecorder()
+		writer := &compressWriter{ResponseWriter: rep, encoding: item.encoding, level: 6, min: 1024, types: []string{"text/*", "application/json"}}
+
+		writer.Header().Set("Content-Type", item.contentType)
+		if item.preset != "" {
+			writer.Header().Set("Content-Encoding", item.preset)
+		}
+
+		writer.WriteHeader(item.status)
+		writer.Write([]byte(item.body))
+		writer.Close()
+
+		if rep.Code != item.status {
+			t.Errorf("%s: expect status %d, got %d", item.name, item.status, rep.Code)
+		}
+
+		encoded := rep.Header().Get("Content-Encoding") == item.encoding
+		if encoded != item.compressed {
+			t.Errorf("%s: expect compressed %v, got header %q", item.name, item.compressed, rep.Header().Get("Content-Encoding"))
+			continue
+		}
+
+		body := rep.Body.Bytes()
+		if encoded && item.encoding == "gzip" {
+			reader, _ := gzip.NewReader(rep.Body)
+			body, _ = ioutil.ReadAll(reader)
+		} else if encoded {
+			body, _ = ioutil.ReadAll(flate.NewReader(rep.Body))
+		}
+
+		if string(body) != item.body {
+			t.Errorf("%s: body mismatch, got %d bytes", item.name, len(body))
+		}
+	}
+}
@@ -16,9 +16,25 @@ type configOfApp struct {
 	Host string
 	Port int
 	Token string
+	Proxy string
 	Static string
+	Cert string
+	Key string
+	Ca string
+	Tls string
+	Redirect int
 	Cpu int
 	Memory int64
+	Shutdown int
+	Drain int
+	HealthTimeout int
+	Metrics string
+	Lang string
+	BodyLimit int64
+	Compress int
+	CompressMin int
+	CompressTypes string
+	Access bool
 	Debug bool
 }
 
@@ -32,12 +48,44 @@ func (this *configOfApp) Set(key string, value string) {
 		this.Port, _ = strconv.Atoi(value)
 	case "token":
 		this.Token = value
+	case "proxy":
+		this.Proxy = value
 	case "static":
 		this.Static = value
+	case "cert":
+		this.Cert = value
+	case "key":
+		this.Key = value
+	case "ca":
+		this.Ca = value
+	case "tls":
+		this.Tls = value
+	case "redirect":
+		this.Redirect, _ = strconv.Atoi(value)
 	case "cpu":
 		this.Cpu, _ = strconv.Atoi(value)
 	case "memory":
 		this.Memory, _ = strconv.ParseInt(value, 10, 64)
+	case "shutdown":
+		this.Shutdown, _ = strconv.Atoi(value)
+	case "drain":
+		this.Drain, _ = strconv.Atoi(value)
+	case "health_timeout":
+		this.HealthTimeout, _ = strconv.Atoi(value)
+	case "body_limit":
+		this.BodyLimit, _ = strconv.ParseInt(value, 10, 64)
+	case "lang":
+		this.Lang = strings.ToLower(value)
+	case "metrics":
+		this.Metrics = value
+	case "compress":
+		this.Compress, _ = strconv.Atoi(value)
+	case "compress_min":
+		this.CompressMin, _ = strconv.Atoi(value)
+	case "compress_types":
+		this.CompressTypes = value
+	case "access":
+		this.Access, _ = strconv.ParseBool(value)
 	case "debug":
 		this.Debug, _ = strconv.ParseBool(value)
 	}
@@ -97,6 +145,8 @@ type configOfTemplate struct {
 	Extension string
 	Cache bool
 	Define string
+	Layout string
+	Error string
 }
 
 func (this *configOfTemplate) Set(key string, value string) {
@@ -107,8 +157,12 @@ func (this *configOfTemplate) Set(key string, value string) {
 		this.Extension = value
 	case "define":
 		this.Define = value
+	case "layout":
+		this.Layout = value
 	case "cache":
 		this.Cache, _ = strconv.ParseBool(value)
+	case "error":
+		this.Error = value
 	}
 }
 
@@ -126,6 +180,125 @@ func (this *configOfGateway) Set(key string, value string) {
 	}
 }
 
+type configOfCors struct {
+	Origin string
+	Methods string
+	Headers string
+	Expose string
+	Credentials bool
+	MaxAge int
+}
+
+func (this *configOfCors) Set(key string, value string) {
+	switch strings.ToLower(key) {
+	case "origin":
+		this.Origin = value
+	case "methods":
+		this.Methods = value
+	case "headers":
+		this.Headers = value
+	case "expose":
+		this.Expose = value
+	case "credentials":
+		this.Credentials, _ = strconv.ParseBool(value)
+	case "maxage":
+		this.MaxAge, _ = strconv.Atoi(value)
+	}
+}
+
+type configOfAdmin struct {
+	Path string
+	Host string
+	Port int
+	Allow string
+}
+
+func (this *configOfAdmin) Set(key string, value string) {
+	switch strings.ToLower(key) {
+	case "path":
+		this.Path = value
+	case "host":
+		this.Host = value
+	case "port":
+		this.Port, _ = strconv.Atoi(value)
+	case "allow":
+		this.Allow = value
+	}
+}
+
+type configOfUpload struct {
+	Path string
+	Max int64
+	Count int
+	Exts string
+	Types string
+	Pixels int64
+}
+
+func (this *configOfUpload) Set(key string, value string) {
+	switch strings.ToLower(key) {
+	case "path":
+		this.Path = value
+	case "max":
+		this.Max, _ = strconv.ParseInt(value, 10, 64)
+	case "count":
+		this.Count, _ = strconv.Atoi(value)
+	case "exts":
+		this.Exts = value
+	case "types":
+		this.Types = value
+	case "pixels":
+		this.Pixels, _ = strconv.ParseInt(value, 10, 64)
+	}
+}
+
+type configOfDownload struct {
+	Offload string
+	Min int64
+	Root string
+	Prefix string
+	Secret string
+	Expire int
+}
+
+func (this *configOfDownload) Set(key string, value string) {
+	switch strings.ToLower(key) {
+	case "offload":
+		this.Offload = strings.ToLower(value)
+	case "min":
+		this.Min, _ = strconv.ParseInt(value, 10, 64)
+	case "root":
+		this.Root = value
+	case "prefix":
+		this.Prefix = value
+	case "secret":
+		this.Secret = value
+	case "expire":
+		this.Expire, _ = strconv.Atoi(value)
+	}
+}
+
+type configOfLimit struct {
+	Rules map[string]string
+}
+
+func (this *configOfLimit) Set(key string, value string) {
+	this.Rules[key] = value
+}
+
+type configOfStatic struct {
+	Mounts map[string]string
+	Cache map[string]int
+}
+
+func (this *configOfStatic) Set(key string, value string) {
+	if strings.HasPrefix(key, "/") {
+		this.Mounts[key] = value
+	} else {
+		this.Cache[strings.ToLower(key)], _ = strconv.Atoi(value)
+	}
+}
+
 type configOfExtend struct {
 	data map[string]map[string]string
 }
@@ -274,6 +447,12 @@ type Config struct {
 	Session *configOfSession
 	Template *configOfTemplate
 	Gateway *configOfGateway
+	Cors *configOfCors
+	Limit *configOfLimit
+	Static *configOfStatic
+	Admin *configOfAdmin
+	Upload *configOfUpload
+	Download *configOfDownload
 	Extend *configOfExtend
 
 	Redis *cache.Config
@@ -333,7 +512,7 @@ func (this *Config) SetSession(node map[string]string) {
 
 func (this *Config) SetTemplate(node map[string]string) {
 	if this.Template == nil {
-		this.Template = &configOfTemplate{}
+		this.Template = &configOfTemplate{Cache: true}
 	}
 
 	for key, value := range node {
@@ -351,6 +530,66 @@ func (this *Config) SetGateway(node map[string]string) {
 	}
 }
 
+func (this *Config) SetCors(node map[string]string) {
+	if this.Cors == nil {
+		this.Cors = &configOfCors{}
+	}
+
+	for key, value := range node {
+		this.Cors.Set(key, this.Constant(value))
+	}
+}
+
+func (this *Config) SetLimit(node map[string]string) {
+	if this.Limit == nil {
+		this.Limit = &configOfLimit{Rules: map[string]string{}}
+	}
+
+	for key, value := range node {
+		this.Limit.Set(key, this.Constant(value))
+	}
+}
+
+func (this *Config) SetAdmin(node map[string]string) {
+	if this.Admin == nil {
+		this.Admin = &configOfAdmin{}
+	}
+
+	for key, value := range node {
+		this.Admin.Set(key, this.Constant(value))
+	}
+}
+
+func (this *Config) SetUpload(node map[string]string) {
+	if this.Upload == nil {
+		this.Upload = &configOfUpload{}
+	}
+
+	for key, value := range node {
+		this.Upload.Set(key, this.Constant(value))
+	}
+}
+
+func (this *Config) SetDownload(node map[string]string) {
+	if this.Download == nil {
+		this.Download = &configOfDownload{}
+	}
+
+	for key, value := range node {
+		this.Download.Set(key, this.Constant(value))
+	}
+}
+
+func (this *Config) SetStatic(node map[string]string) {
+	if this.Static == nil {
+		this.Static = &configOfStatic{Mounts: map[string]string{}, Cache: map[string]int{}}
+	}
+
+	for key, value := range node {
+		this.Static.Set(key, this.Constant(value))
+	}
+}
+
 func (this *Config) SetExtend(section string, node map[string]string) {
 	if this.Extend == nil {
 		this.Extend = &configOfExtend{}
@@ -495,6 +734,18 @@ func (this *Config) Load(path string) {
 			this.SetTemplate(node)
 		case "gateway":
 			this.SetGateway(node)
+		case "cors":
+			this.SetCors(node)
+		case "limit":
+			this.SetLimit(node)
+		case "static":
+			this.SetStatic(node)
+		case "admin":
+			this.SetAdmin(node)
+		case "upload":
+			this.SetUpload(node)
+		case "download":
+			this.SetDownload(node)
 		case "redis":
 			this.SetRedis(node)
 		case "mysql":
@@ -1,11 +1,10 @@
 package tec
 
 import (
+	"bytes"
 	"encoding/xml"
-	"fmt"
-	"html/template"
+	"github.com/agilecho/tec/ws"
 	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net"
 	"net/http"
@@ -13,13 +12,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 )
 
 type Context struct {
 	Request *http.Request
 	Response http.ResponseWriter
+	RequestId string
 
 	IsTLS bool
 	IsRobot bool
@@ -46,6 +45,7 @@ type Context struct {
 
 	Header map[string]string
 	Param map[string]string
+	PathParam map[string]string
 	Query map[string]string
 	Form map[string]string
 
@@ -55,12 +55,24 @@ type Context struct {
 	Session Session
 	Setting map[string]interface{}
 
+	app *App
+	writer *responseWriter
+	route string
+	body []byte
+	data interface{}
+	overflow bool
+	sse *SSE
+	format string
+	layout string
+	layouted bool
+	status int
 	afterFilter []AfterFilterFunc
 }
 
 func (this *Context) Reset() {
 	this.Request = nil
 	this.Response = nil
+	this.RequestId = ""
 
 	this.IsTLS = false
 	this.IsRobot = false
@@ -87,6 +99,7 @@ func (this *Context) Reset() {
 
 	this.Header = nil
 	this.Param = nil
+	this.PathParam = nil
 	this.Query = nil
 	this.Form = nil
 
@@ -95,7 +108,18 @@ func (this *Context) Reset() {
 
 	this.Session = nil
 	this.Setting = nil
-	
+
+	this.app = nil
+	this.writer = nil
+	this.route = ""
+	this.body = nil
+	this.data = nil
+	this.overflow = false
+	this.sse = nil
+	this.format = ""
+	this.layout = ""
+	this.layouted = false
+	this.status = 0
 	this.afterFilter = []AfterFilterFunc{}
 }
 
@@ -110,7 +134,7 @@ func (this *Context) Init() {
 
 	u, err := url.Parse(this.Uri)
 	if err != nil {
-		Logger("context.Init error:" + err.Error(), "error")
+		this.Logger("context.Init error:" + err.Error(), "error")
 		return
 	}
 
@@ -147,7 +171,7 @@ func (this *Context) Init() {
 		this.IsWeiXin = true
 	}
 
-	if header, ok := headers["Upgrade"]; ok && header == "XMLHttpRequest" {
+	if header, ok := headers["X-Requested-With"]; ok && strings.EqualFold(header, "XMLHttpRequest") {
 		this.IsAjax = true
 	}
 
@@ -213,19 +237,10 @@ func (this *Context) Init() {
 
 		this.Files = this.Request.MultipartForm.File
 	} else if strings.Contains(headers["Content-Type"], "application/json") {
-		body, _ := ioutil.ReadAll(this.Request.Body)
-
-		params := JsonDecode(string(body))
-		if params != nil {
-			for key, value := range params {
-				switch value.(type) {
-				case bool:
-					formJson[key] = strconv.FormatBool(value.(bool))
-				case string:
-					formJson[key] = value.(string)
-				case float64:
-					formJson[key] = strconv.FormatFloat(value.(float64), 'f', -1, 64)
-				}
+		for key, value := range this.JsonObject("") {
+			switch value.(type) {
+			case bool, string, int64, float64:
+				formJson[key] = jsonString(value)
 			}
 		}
 	} else {
@@ -254,6 +269,22 @@ func (this *Context) Init() {
 	this.Setting = map[string]interface{}{}
 }
 
+func (this *Context) Logger(args ...string) {
+	if len(args) > 0 && this.RequestId != "" {
+		args[0] = "[" + this.RequestId + "] " + args[0]
+	}
+
+	Logger(args...)
+}
+
+func WSContext(req *ws.Request) *Context {
+	if ctx, ok := req.Context.(*Context); ok {
+		return ctx
+	}
+
+	return nil
+}
+
 func (this *Context) Dispatch() []string {
 	return []string {this.Module, this.Controller, this.Action}
 }
@@ -275,23 +306,27 @@ func (this *Context) Cookie(name, value string, expire int) {
 }
 
 func (this *Context) Save(name string, target string) bool {
-	if target[0:1] != "/" {
-		target = "/" + target
+	if len(this.Files[name]) == 0 {
+		return false
 	}
 
-	path := PUBLIC_PATH + target
+	path, err := uploadPath(target)
+	if err != nil {
+		this.Logger("context.Save path error:" + target, "error")
+		return false
+	}
 
 	if !IsDir(filepath.Dir(path)) {
 		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
 		if err != nil {
-			Logger("context.Save MkdirAll error:" + err.Error(), "error")
+			this.Logger("context.Save MkdirAll error:" + err.Error(), "error")
 			return false
 		}
 	}
 
 	file, err := this.Files[name][0].Open()
 	if err != nil {
-		Logger("context.Save Open error:" + err.Error(), "error")
+		this.Logger("context.Save Open error:" + err.Error(), "error")
 		return false
 	}
 
@@ -299,7 +334,7 @@ func (this *Context) Save(name string, target string) bool {
 
 	out, err := os.Create(path)
 	if err != nil {
-		Logger("context.Save os.Create error:" + err.Error(), "error")
+		this.Logger("context.Save os.Create error:" + err.Error(), "error")
 		return false
 	}
 
@@ -307,7 +342,7 @@ func (this *Context) Save(name string, target string) bool {
 
 	_, err = io.Copy(out, file)
 	if err != nil {
-		Logger("context.Save io.Copy error:" + err.Error(), "error")
+		this.Logger("context.Save io.Copy error:" + err.Error(), "error")
 		return false
 	}
 
@@ -315,31 +350,35 @@ func (this *Context) Save(name string, target string) bool {
 }
 
 func (this *Context) Download(file string, name string) {
-	if _, err := os.Stat(file); err != nil {
-		http.ServeFile(this.Response, this.Request, file)
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		this.Status(http.StatusNotFound)
+		this.Json(Result{Code: http.StatusNotFound, Msg: "can not find download file:" + name})
 		return
 	}
 
-	filename := url.PathEscape(name)
-	if name == filename {
-		filename = "filename=" + filename
-	} else {
-		filename = "filename=" + name + "; filename*=utf-8''" + filename
-	}
-
 	this.invokeAfter("Download", file)
 
-	this.Response.Header().Set("Content-Disposition", "attachment; " + filename)
-	this.Response.Header().Set("Content-Description", "File Transfer")
-	this.Response.Header().Set("Content-Type", "application/octet-stream")
-	this.Response.Header().Set("Content-Transfer-Encoding", "binary")
-	this.Response.Header().Set("Expires", "0")
-	this.Response.Header().Set("Cache-Control", "must-revalidate")
-	this.Response.Header().Set("Pragma", "public")
+	this.downloadHeader(name)
+
+	if this.downloadOffload(file, info.Size()) {
+		return
+	}
 
 	http.ServeFile(this.Response, this.Request, file)
 }
 
+func (this *Context) Status(code int) {
+	this.status = code
+}
+
+func (this *Context) writeHeader() {
+	if this.status > 0 {
+		this.Response.WriteHeader(this.status)
+		this.status = 0
+	}
+}
+
 func (this *Context) Text(args ...string) {
 	this.invokeAfter("Text", args[0])
 
@@ -350,22 +389,27 @@ func (this *Context) Text(args ...string) {
 
 	this.Response.Header().Set("Content-Type", "text/html")
 	this.Response.Header().Set("Charset", chartset)
+	this.writeHeader()
 
 	_, err := this.Response.Write([]byte(args[0]))
 	if err != nil {
-		Logger("context.Text error:" + err.Error(), "error")
+		this.Logger("context.Text error:" + err.Error(), "error")
 	}
 }
 
 func (this *Context) Json(data interface{}) {
 	this.invokeAfter("Json", data)
+	this.writeJson(data)
+}
 
+func (this *Context) writeJson(data interface{}) {
 	this.Response.Header().Set("Content-Type", "application/json")
 	this.Response.Header().Set("Charset", "UTF-8")
+	this.writeHeader()
 
 	_, err := this.Response.Write([]byte(JsonEncode(data)))
 	if err != nil {
-		Logger("context.Json error:" + err.Error(), "error")
+		this.Logger("context.Json error:" + err.Error(), "error")
 	}
 }
 
@@ -402,6 +446,7 @@ func (this *Context) Render(file string, data map[string]interface{}) {
 		CONFIG.Template = &configOfTemplate{
 			Path: ROOT_PATH + "/app",
 			Extension: ".html",
+			Cache: true,
 		}
 	}
 
@@ -450,6 +495,8 @@ func (this *Context) Render(file string, data map[string]interface{}) {
 		data["session"] = this.Session.Get("")
 	}
 
+	this.templateGlobals(data)
+
 	this.invokeAfter("Render", []interface{}{file, data})
 
 	this.Response.Header().Set("Content-Type", "text/html")
@@ -460,72 +507,31 @@ func (this *Context) Render(file string, data map[string]interface{}) {
 		return
 	}
 
-	files := []string{CONFIG.Template.Path + file + CONFIG.Template.Extension}
+	templates := newTemplateManager(nil)
+	if this.app != nil {
+		templates = this.app.templates
+	}
 
-	if CONFIG.Template.Define != "" {
-		defines := strings.Split(CONFIG.Template.Define, ",")
-		if len(defines) > 0 {
-			for _, value := range defines {
-				files = append(files, CONFIG.Template.Path + value + CONFIG.Template.Extension)
-			}
-		}
+	entry, err := templates.get(this.templateNames(file))
+	if err != nil {
+		this.templateError(err)
+		return
 	}
 
-	tpl := template.New(BaseName(files[0]))
-	tpl = tpl.Funcs(template.FuncMap{
-		"Println": fmt.Println,
-		"Sprintf": fmt.Sprintf,
-
-		"IdEnCode": IdEnCode,
-		"IdDeCode": IdDeCode,
-		"UcFirst": UcFirst,
-		"StripWords": StripWords,
-		"CutString": CutString,
-		"SubTimer": SubTimer,
-		"TimeSpan": TimeSpan,
-		"StripTags": StripTags,
-		
-		"Add": Add,
-		"Subtract": Subtract,
-		"Multiply": Multiply,
-		"Divide": Divide,
-		"Round": Round,
-		"Floor": Floor,
-		"Ceil": Ceil,
-		"Max": Max,
-		"Min": Min,
-		"Rand": Rand,
-		"Random": Random,
-
-		"FormatInt": FormatInt,
-		"FormatInt64": FormatInt64,
-		"FormatFloat64": FormatFloat64,
-		"FormatBytes": FormatBytes,
-		"FormatDiscount": FormatDiscount,
-		"FormatMobilePrivacy": FormatMobilePrivacy,
-		"FormatPrice": FormatPrice,
-		"FormatTime": FormatTime,
-
-		"Loop": Loop,
-		"Pager": Pager,
-
-		"EQ": func(param map[string]string, key, value string) bool {
-			if val, ok := param[key]; ok {
-				return  value == val
-			}
+	buffer := templateBuffers.Get().(*bytes.Buffer)
+	buffer.Reset()
 
-			return false
-		},
-	})
+	defer templateBuffers.Put(buffer)
 
-	tpl, err := tpl.ParseFiles(files...)
-	if err != nil {
-		Logger("context.Render ParseFiles error:" + err.Error(), "error")
+	if err = entry.tpl.ExecuteTemplate(buffer, entry.name, data); err != nil {
+		this.templateError(err)
+		return
 	}
 
-	err = tpl.Execute(this.Response, data)
-	if err != nil {
-		Logger("context.Render Execute error:" + err.Error(), "error")
+	this.writeHeader()
+
+	if _, err = this.Response.Write(buffer.Bytes()); err != nil {
+		this.Logger("context.Render Write error:" + err.Error(), "error")
 	}
 }
 
@@ -545,14 +551,27 @@ func (this *Context) Message(file string, args ...string)  {
 func (this *Context) XML(data interface{}) {
 	this.invokeAfter("XML", data)
 
+	content, err := xml.Marshal(data)
+	if err != nil {
+		this.Logger("context.XML error:" + err.Error(), "error")
+
+		this.Status(http.StatusInternalServerError)
+		this.writeJson(Result{Code: http.StatusInternalServerError, Msg: "xml marshal error"})
+		return
+	}
+
+	this.writeXML(content)
+}
+
+func (this *Context) writeXML(content []byte) {
 	this.Response.Header().Set("Content-Type", "application/xml")
 	this.Response.Header().Set("Charset", "UTF-8")
 
-	content, _ := xml.Marshal(data)
+	this.writeHeader()
 
 	_, err := this.Response.Write(content)
 	if err != nil {
-		Logger("context.XML error:" + err.Error(), "error")
+		this.Logger("context.XML error:" + err.Error(), "error")
 	}
 }
 
@@ -567,6 +586,12 @@ func (this *Context) Redirect(url string) {
 }
 
 func (this *Context) Close() {
+	this.writeHeader()
+
+	if this.sse != nil {
+		this.sse.Close()
+	}
+
 	if this.Session != nil {
 		this.Session.Close()
 	}
@@ -0,0 +1,114 @@
+package tec
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func corsSplit(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func corsMatch(origins []string, origin string) bool {
+	for _, item := range origins {
+		if item == "*" || strings.EqualFold(item, origin) {
+			return true
+		}
+
+		index := strings.Index(item, "*")
+		if index == -1 {
+			continue
+		}
+
+		prefix := strings.ToLower(item[0:index])
+		suffix := strings.ToLower(item[index + 1:])
+		value := strings.ToLower(origin)
+
+		if len(value) > len(prefix) + len(suffix) && strings.HasPrefix(value, prefix) && strings.HasSuffix(value, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func corsFilter(config *configOfCors) BeforeFilterFunc {
+	origins := corsSplit(config.Origin)
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+
+	methods := strings.Join(corsSplit(config.Methods), ", ")
+	if methods == "" {
+		methods = "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS"
+	}
+
+	headers := strings.Join(corsSplit(config.Headers), ", ")
+	expose := strings.Join(corsSplit(config.Expose), ", ")
+
+	credentials := config.Credentials
+	if credentials && InArray("*", origins) {
+		Logger("cors origin * can not be used with credentials, credentials disabled", "error", "false")
+		credentials = false
+	}
+
+	return func(ctx *Context) bool {
+		origin := ctx.Request.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		header := ctx.Response.Header()
+		header.Add("Vary", "Origin")
+
+		if !corsMatch(origins, origin) {
+			return true
+		}
+
+		if InArray("*", origins) {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if credentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if ctx.Method != "OPTIONS" || ctx.Request.Header.Get("Access-Control-Request-Method") == "" {
+			if expose != "" {
+				header.Set("Access-Control-Expose-Headers", expose)
+			}
+
+			return true
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+
+		header.Set("Access-Control-Allow-Methods", methods)
+
+		if headers != "" {
+			header.Set("Access-Control-Allow-Headers", headers)
+		} else if request := ctx.Request.Header.Get("Access-Control-Request-Headers"); request != "" {
+			header.Set("Access-Control-Allow-Headers", request)
+		}
+
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+		}
+
+		ctx.Response.WriteHeader(http.StatusNoContent)
+
+		return false
+	}
+}
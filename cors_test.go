@@ -0,0 +1,35 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorsWildcardCredentials(t *testing.T) {
+	filter := corsFilter(&configOfCors{Origin: "*", Credentials: true})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Origin", "https://evil.com")
+
+	filter(&Context{Request: request, Response: recorder, Method: "GET"})
+
+	if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Fatalf("allow origin:%s", origin)
+	}
+
+	if recorder.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("credentials sent with wildcard origin")
+	}
+
+	filter = corsFilter(&configOfCors{Origin: "https://*.demo.com", Credentials: true})
+
+	recorder = httptest.NewRecorder()
+	request.Header.Set("Origin", "https://www.demo.com")
+
+	filter(&Context{Request: request, Response: recorder, Method: "GET"})
+
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "https://www.demo.com" || recorder.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatal("credentials missing for matched origin")
+	}
+}
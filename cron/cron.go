@@ -3,6 +3,7 @@ package cron
 import (
 	"context"
 	"fmt"
+	"github.com/agilecho/tec/metrics"
 	"os"
 	"sort"
 	"strconv"
@@ -11,10 +12,23 @@ import (
 	"time"
 )
 
+var runTotal = metrics.NewCounter("cron_job_runs_total", "Total number of cron job runs.", "task")
+var failTotal = metrics.NewCounter("cron_job_failures_total", "Total number of cron job runs that panicked.", "task")
+
 type entryID int
 
+type Entry struct {
+	ID int `json:"id"`
+	Name string `json:"name"`
+	Spec string `json:"spec"`
+	Next time.Time `json:"next"`
+	Prev time.Time `json:"prev"`
+}
+
 type entry struct {
 	ID entryID
+	Name string
+	Spec string
 	Schedule schedule
 	Next time.Time
 	Prev time.Time
@@ -99,6 +113,7 @@ type Cron struct {
 	stop chan struct{}
 	add chan *entry
 	remove chan entryID
+	snapshot chan chan []Entry
 	running bool
 	runningMu sync.Mutex
 	parser scheduleParser
@@ -160,7 +175,7 @@ func (this *Cron) run() {
 					if e.Next.After(now) || e.Next.IsZero() {
 						break
 					}
-					this.startJob(e.WrappedJob)
+					this.startJob(e)
 					e.Prev = e.Next
 					e.Next = e.Schedule.Next(now)
 					this.logger(fmt.Sprintf("%v:%v %v:%v %v:%v", "run now", now.Format("2006-01-02 15:04:05"), "entry", e.ID, "next", e.Next.Format("2006-01-02 15:04:05")))
@@ -182,6 +197,10 @@ func (this *Cron) run() {
 				timer.Stop()
 				this.removeEntry(id)
 				this.logger(fmt.Sprintf("%v %v:%v", "removed", "entry", id))
+
+			case reply := <-this.snapshot:
+				reply <- this.entrySnapshot()
+				continue
 			}
 
 			break
@@ -189,15 +208,34 @@ func (this *Cron) run() {
 	}
 }
 
-func (this *Cron) startJob(j job) {
+func (this *Cron) startJob(e *entry) {
 	this.jobWaiter.Add(1)
 
+	id := strconv.Itoa(int(e.ID))
+	runTotal.Inc(e.Name)
+
 	go func() {
 		defer this.jobWaiter.Done()
-		j.Run()
+		defer func() {
+			if err := recover(); err != nil {
+				failTotal.Inc(e.Name)
+				this.logger(fmt.Sprintf("%v:%v %v:%v", "job panic", err, "entry", id))
+			}
+		}()
+
+		e.WrappedJob.Run()
 	}()
 }
 
+func (this *Cron) entrySnapshot() []Entry {
+	entries := make([]Entry, 0, len(this.entries))
+	for _, e := range this.entries {
+		entries = append(entries, Entry{ID: int(e.ID), Name: e.Name, Spec: e.Spec, Next: e.Next, Prev: e.Prev})
+	}
+
+	return entries
+}
+
 func (this *Cron) removeEntry(id entryID) {
 	var entries []*entry
 	for _, e := range this.entries {
@@ -210,6 +248,19 @@ func (this *Cron) removeEntry(id entryID) {
 }
 
 func (this *Cron) Add(spec string, fun func()) *Cron {
+	return this.addEntry(spec, spec, fun)
+}
+
+func (this *Cron) Task(name string, fun func()) *Cron {
+	if this.config == nil || this.config.Schedules[name] == "" {
+		this.logger("add job error:can not find schedule " + name)
+		return this
+	}
+
+	return this.addEntry(name, this.config.Schedules[name], fun)
+}
+
+func (this *Cron) addEntry(name string, spec string, fun func()) *Cron {
 	schedule, err := this.parser.Parse(spec)
 	if err != nil {
 		this.logger("add job error:" + err.Error())
@@ -224,6 +275,8 @@ func (this *Cron) Add(spec string, fun func()) *Cron {
 	this.nextID++
 	entry := &entry{
 		ID: this.nextID,
+		Name: name,
+		Spec: spec,
 		Schedule: schedule,
 		WrappedJob: this.chain.Then(cmd),
 		Job: cmd,
@@ -238,6 +291,19 @@ func (this *Cron) Add(spec string, fun func()) *Cron {
 	return this
 }
 
+func (this *Cron) Entries() []Entry {
+	this.runningMu.Lock()
+	defer this.runningMu.Unlock()
+
+	if this.running {
+		reply := make(chan []Entry, 1)
+		this.snapshot <- reply
+		return <-reply
+	}
+
+	return this.entrySnapshot()
+}
+
 func (this *Cron) Start() {
 	this.runningMu.Lock()
 	defer this.runningMu.Unlock()
@@ -277,6 +343,7 @@ func New(config *Config) *Cron {
 		add: make(chan *entry),
 		stop: make(chan struct{}),
 		remove: make(chan entryID),
+		snapshot: make(chan chan []Entry),
 		running: false,
 		runningMu: sync.Mutex{},
 		parser: standardParser,
@@ -293,6 +360,14 @@ func Add(spec string, fun func()) *Cron {
 	return handler.Add(spec, fun)
 }
 
-func Stop()  {
-	handler.Stop()
-}
\ No newline at end of file
+func Task(name string, fun func()) *Cron {
+	return handler.Task(name, fun)
+}
+
+func Stop() context.Context {
+	return handler.Stop()
+}
+
+func Entries() []Entry {
+	return handler.Entries()
+}
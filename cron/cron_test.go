@@ -0,0 +1,25 @@
+package cron
+
+import (
+	"testing"
+)
+
+func TestTaskName(t *testing.T) {
+	cron := New(&Config{Schedules: map[string]string{"report": "0 * * * * ?", "clean": "0 * * * * ?"}})
+
+	cron.Task("report", func() {})
+	cron.Task("clean", func() {})
+	cron.Task("missing", func() {})
+	cron.Add("0 * * * * ?", func() {})
+
+	entries := cron.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expect 3 entries, got %d", len(entries))
+	}
+
+	for i, name := range []string{"report", "clean", "0 * * * * ?"} {
+		if entries[i].Name != name || entries[i].Spec != "0 * * * * ?" {
+			t.Errorf("entry %d: expect %s, got %+v", i, name, entries[i])
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"encoding/json"
 	"fmt"
 	"github.com/agilecho/tec/db/mysql"
+	"github.com/agilecho/tec/metrics"
 	"math/rand"
 	"os"
 	"reflect"
@@ -14,6 +17,19 @@ import (
 	"time"
 )
 
+var queryTotal = metrics.NewCounter("db_queries_total", "Total number of database statements.", "type", "status")
+var queryDuration = metrics.NewHistogram("db_query_duration_seconds", "Database statement latency in seconds.", nil, "type")
+
+func observe(kind string, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	queryTotal.Inc(kind, status)
+	queryDuration.Observe(time.Since(start).Seconds(), kind)
+}
+
 type argWrapper struct {
 	Args []interface{}
 	Callback func(rows *sql.Rows)
@@ -330,7 +346,11 @@ func (this *TxWrapper) Execute(tsql string, args ...interface{}) sql.Result {
 		this.db.logger("db.TxWrapper.Execute tsql:" + tsql + " arg:" + string(result))
 	}
 
+	start := time.Now()
+
 	result, err := this.handler.Exec(tsql, args...)
+	observe("execute", start, err)
+
 	if err != nil {
 		this.db.logger("db.TxWrapper.Execute.Exec error:" + err.Error())
 		return nil
@@ -513,19 +533,28 @@ func (this *Db) query(tsql string, args ...interface{}) (*sql.Rows, error) {
 		return nil, nil
 	}
 
+	start := time.Now()
+
 	if len(args) == 0 {
-		return link.handler.Query(tsql)
+		rows, err := link.handler.Query(tsql)
+		observe("query", start, err)
+
+		return rows, err
 	} else {
 		var stmt *sql.Stmt
 
 		stmt, err := link.handler.Prepare(tsql)
 		if err != nil {
+			observe("query", start, err)
 			return nil, err
 		}
 
 		defer stmt.Close()
 
-		return stmt.Query(args...)
+		rows, err := stmt.Query(args...)
+		observe("query", start, err)
+
+		return rows, err
 	}
 }
 
@@ -701,6 +730,11 @@ func (this *Db) Execute(tsql string, args ...interface{}) sql.Result {
 		return nil
 	}
 
+	start := time.Now()
+	defer func() {
+		observe("execute", start, err)
+	}()
+
 	if len(args) == 0 {
 		result, err = link.handler.Exec(tsql)
 	} else {
@@ -755,6 +789,40 @@ func (this *Db) Ping() {
 	}
 }
 
+func (this *Db) Check(ctx context.Context) map[string]error {
+	links := map[string]*linkWrapper{"master": this.linkMaster}
+	for i, link := range this.linkSlaves {
+		links["slave" + strconv.Itoa(i)] = link
+	}
+
+	result := map[string]error{}
+	mutex := sync.Mutex{}
+	group := sync.WaitGroup{}
+
+	for name, link := range links {
+		group.Add(1)
+
+		go func(name string, link *linkWrapper) {
+			defer group.Done()
+
+			var err error
+			if link == nil {
+				err = errors.New("db link not open")
+			} else {
+				err = link.handler.PingContext(ctx)
+			}
+
+			mutex.Lock()
+			result[name] = err
+			mutex.Unlock()
+		}(name, link)
+	}
+
+	group.Wait()
+
+	return result
+}
+
 func (this *Db) Close() {
 	if this.linkMaster != nil {
 		this.linkMaster.handler.Close()
@@ -850,6 +918,10 @@ func Ping() {
 	handler.Ping()
 }
 
+func Check(ctx context.Context) map[string]error {
+	return handler.Check(ctx)
+}
+
 func Close() {
 	handler.Close()
 }
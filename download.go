@@ -0,0 +1,172 @@
+package tec
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (this *Context) downloadHeader(name string) {
+	filename := url.PathEscape(name)
+	if name == filename {
+		filename = "filename=" + filename
+	} else {
+		filename = "filename=" + strings.Replace(name, "\"", "", -1) + "; filename*=utf-8''" + filename
+	}
+
+	if compress, ok := this.Response.(*compressWriter); ok {
+		compress.Disable()
+	}
+
+	this.Response.Header().Set("Content-Disposition", "attachment; " + filename)
+	this.Response.Header().Set("Content-Description", "File Transfer")
+	this.Response.Header().Set("Content-Type", "application/octet-stream")
+	this.Response.Header().Set("Content-Transfer-Encoding", "binary")
+	this.Response.Header().Set("Expires", "0")
+	this.Response.Header().Set("Cache-Control", "must-revalidate")
+	this.Response.Header().Set("Pragma", "public")
+}
+
+func (this *Context) downloadOffload(file string, size int64) bool {
+	config := CONFIG.Download
+	if config == nil || config.Offload == "" || config.Offload == "none" || size < config.Min {
+		return false
+	}
+
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+
+	switch config.Offload {
+	case "nginx", "x-accel-redirect":
+		root, err := filepath.Abs(config.Root)
+		if config.Root == "" || err != nil || !strings.HasPrefix(path, root + string(filepath.Separator)) {
+			return false
+		}
+
+		uri := strings.TrimRight(config.Prefix, "/") + filepath.ToSlash(path[len(root):])
+
+		this.Response.Header().Set("X-Accel-Redirect", (&url.URL{Path: uri}).EscapedPath())
+	case "apache", "lighttpd", "x-sendfile":
+		this.Response.Header().Set("X-Sendfile", path)
+	default:
+		return false
+	}
+
+	this.Response.Header().Del("Content-Type")
+	this.writeHeader()
+
+	return true
+}
+
+func (this *Context) DownloadContent(content io.ReadSeeker, name string, args ...time.Time) {
+	modtime := time.Time{}
+	if len(args) > 0 {
+		modtime = args[0]
+	}
+
+	this.invokeAfter("Download", name)
+
+	this.downloadHeader(name)
+
+	http.ServeContent(this.Response, this.Request, name, modtime, content)
+}
+
+func (this *Context) DownloadBytes(data []byte, name string, args ...time.Time) {
+	this.DownloadContent(bytes.NewReader(data), name, args...)
+}
+
+func downloadSecret() string {
+	if CONFIG.Download != nil && CONFIG.Download.Secret != "" {
+		return CONFIG.Download.Secret
+	}
+
+	if CONFIG.App != nil {
+		return CONFIG.App.Token
+	}
+
+	return ""
+}
+
+func downloadSign(path string, query url.Values) string {
+	mac := hmac.New(sha256.New, []byte(downloadSecret()))
+	mac.Write([]byte(path + "?" + query.Encode()))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func SignURL(uri string, args ...time.Duration) string {
+	expire := time.Hour
+	if len(args) > 0 && args[0] > 0 {
+		expire = args[0]
+	} else if CONFIG.Download != nil && CONFIG.Download.Expire > 0 {
+		expire = time.Duration(CONFIG.Download.Expire) * time.Second
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	query := u.Query()
+	query.Del("signature")
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expire).Unix(), 10))
+	query.Set("signature", downloadSign(u.EscapedPath(), query))
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func VerifyURL(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+
+	if downloadSecret() == "" {
+		return errors.New("signature secret not configured")
+	}
+
+	query := u.Query()
+
+	signature := query.Get("signature")
+	if signature == "" {
+		return errors.New("signature required")
+	}
+
+	query.Del("signature")
+
+	if !hmac.Equal([]byte(signature), []byte(downloadSign(u.EscapedPath(), query))) {
+		return errors.New("signature invalid")
+	}
+
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if expires < time.Now().Unix() {
+		return errors.New("signature expired")
+	}
+
+	return nil
+}
+
+func Signed() BeforeFilterFunc {
+	return func(ctx *Context) bool {
+		if err := VerifyURL(ctx.Request.URL.RequestURI()); err != nil {
+			ctx.Status(http.StatusForbidden)
+			ctx.Json(Result{Code: http.StatusForbidden, Msg: err.Error()})
+			return false
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,176 @@
+package tec
+
+import (
+	"context"
+	"fmt"
+	"github.com/agilecho/tec/cache"
+	"github.com/agilecho/tec/db"
+	"github.com/agilecho/tec/mongo"
+	"github.com/agilecho/tec/mq"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type HealthFunc func(ctx context.Context) error
+
+type healthState struct {
+	Status string `json:"status"`
+	Error string `json:"error,omitempty"`
+	Time int64 `json:"time"`
+}
+
+type healthCheck struct {
+	name string
+	fun func(ctx context.Context) map[string]error
+}
+
+func (this *App) Health(name string, fun HealthFunc) {
+	this.healthFunc = append(this.healthFunc, healthCheck{name: name, fun: func(ctx context.Context) map[string]error {
+		return map[string]error{"": fun(ctx)}
+	}})
+}
+
+func (this *App) healthChecks() []healthCheck {
+	checks := []healthCheck{}
+
+	if this.Config.MySQL != nil {
+		checks = append(checks, healthCheck{name: "mysql", fun: db.Check})
+	}
+
+	if this.Config.Redis != nil {
+		checks = append(checks, healthCheck{name: "redis", fun: func(ctx context.Context) map[string]error {
+			return map[string]error{"": cache.Check(ctx)}
+		}})
+	}
+
+	if this.Config.Mongo != nil {
+		checks = append(checks, healthCheck{name: "mongo", fun: func(ctx context.Context) map[string]error {
+			return map[string]error{"": mongo.Check(ctx)}
+		}})
+	}
+
+	if this.Config.MQ != nil {
+		checks = append(checks, healthCheck{name: "mq", fun: func(ctx context.Context) map[string]error {
+			return map[string]error{"": mq.Check()}
+		}})
+	}
+
+	return append(checks, this.healthFunc...)
+}
+
+func (this *App) healthRun(ctx context.Context, check healthCheck) map[string]error {
+	done := make(chan map[string]error, 1)
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- map[string]error{"": fmt.Errorf("panic: %v", err)}
+			}
+		}()
+
+		done <- check.fun(ctx)
+	}()
+
+	select {
+	case result := <- done:
+		return result
+	case <- ctx.Done():
+		return map[string]error{"": ctx.Err()}
+	}
+}
+
+func (this *App) healthLive(ctx *Context) {
+	ctx.Json(Result{Code: http.StatusOK, Msg: "ok"})
+}
+
+func (this *App) healthReady(ctx *Context) {
+	timeout := 3
+	if this.Config.App != nil && this.Config.App.HealthTimeout > 0 {
+		timeout = this.Config.App.HealthTimeout
+	}
+
+	c, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(timeout) * time.Second)
+	defer cancel()
+
+	states := map[string]*healthState{}
+	mutex := sync.Mutex{}
+	group := sync.WaitGroup{}
+
+	for _, check := range this.healthChecks() {
+		group.Add(1)
+
+		go func(check healthCheck) {
+			defer group.Done()
+
+			start := time.Now()
+			result := this.healthRun(c, check)
+			elapsed := time.Since(start).Nanoseconds() / 1e6
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			for key, err := range result {
+				name := check.name
+				if key != "" {
+					name += "." + key
+				}
+
+				state := &healthState{Status: "up", Time: elapsed}
+				if err != nil {
+					state.Status = "down"
+					state.Error = err.Error()
+				}
+
+				states[name] = state
+			}
+		}(check)
+	}
+
+	group.Wait()
+
+	failed := []string{}
+	for name, state := range states {
+		if state.Status != "up" {
+			failed = append(failed, name)
+		}
+	}
+
+	sort.Strings(failed)
+
+	select {
+	case <- this.done:
+		failed = append([]string{"shutdown"}, failed...)
+		states["shutdown"] = &healthState{Status: "down", Error: "server shutting down"}
+	default:
+	}
+
+	if len(failed) > 0 {
+		ctx.Status(http.StatusServiceUnavailable)
+		ctx.Json(Result{Code: http.StatusServiceUnavailable, Msg: "unavailable:" + strings.Join(failed, ","), Data: states})
+		return
+	}
+
+	ctx.Json(Result{Code: http.StatusOK, Msg: "ok", Data: states})
+}
+
+func (this *App) healthRoutes() {
+	routes := map[string]Handler{"/health/live": this.healthLive, "/health/ready": this.healthReady}
+	router := &Router{table: this.Router.table}
+
+	for path, handler := range routes {
+		if fun, _, _ := this.Router.find(path + "/index", "GET"); fun != nil {
+			continue
+		}
+
+		router.GET(path, handler)
+
+		if this.bare == nil {
+			this.bare = map[string]bool{}
+		}
+
+		this.bare[path] = true
+	}
+}
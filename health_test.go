@@ -0,0 +1,43 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthSkipsFilters(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	deny := func(ctx *Context) bool {
+		ctx.Status(401)
+		ctx.Json(Result{Code: 401, Msg: "unauthorized"})
+		return false
+	}
+
+	app.Before(deny)
+	app.Router.Before(deny)
+	app.healthRoutes()
+
+	app.Router.GET("/private", func(ctx *Context) {
+		ctx.Text("private")
+	})
+
+	for path, code := range map[string]int{"/health/live": 200, "/health/ready": 200, "/private": 401} {
+		rep := httptest.NewRecorder()
+		app.Handler(rep, httptest.NewRequest("GET", path, nil))
+
+		if rep.Code != code {
+			t.Errorf("%s: expect %d, got %d", path, code, rep.Code)
+		}
+	}
+
+	close(app.done)
+
+	rep := httptest.NewRecorder()
+	app.Handler(rep, httptest.NewRequest("GET", "/health/ready", nil))
+
+	if rep.Code != 503 {
+		t.Errorf("expect 503 once shutdown starts, got %d", rep.Code)
+	}
+}
@@ -0,0 +1,391 @@
+package tec
+
+import (
+	"github.com/agilecho/tec/cache"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const limitBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(data[1]) or burst
+local last = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return {tostring(allowed), tostring(tokens)}
+`
+
+const limitWindowScript = `
+local period = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local index = math.floor(now / period)
+local current = KEYS[1] .. ':' .. index
+local prev = tonumber(redis.call('GET', KEYS[1] .. ':' .. (index - 1)) or '0')
+local curr = tonumber(redis.call('GET', current) or '0')
+local elapsed = now - index * period
+local allowed = 0
+if prev * (period - elapsed) / period + curr + 1 <= limit then
+	curr = redis.call('INCR', current)
+	redis.call('PEXPIRE', current, period * 2)
+	allowed = 1
+end
+return {tostring(allowed), tostring(prev), tostring(curr), tostring(elapsed)}
+`
+
+type LimitKeyFunc func(ctx *Context) string
+
+var limitProxies sync.Map
+
+func limitTrusted(ip net.IP) bool {
+	if ip == nil || CONFIG == nil || CONFIG.App == nil || CONFIG.App.Proxy == "" {
+		return false
+	}
+
+	networks, ok := limitProxies.Load(CONFIG.App.Proxy)
+	if !ok {
+		networks, _ = limitProxies.LoadOrStore(CONFIG.App.Proxy, adminNetworks(CONFIG.App.Proxy))
+	}
+
+	for _, network := range networks.([]*net.IPNet) {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func limitIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	if !limitTrusted(net.ParseIP(host)) {
+		return host
+	}
+
+	items := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(items) - 1; i >= 0; i-- {
+		item := strings.TrimSpace(items[i])
+		if ip := net.ParseIP(item); ip != nil && !limitTrusted(ip) {
+			return item
+		}
+	}
+
+	return host
+}
+
+func LimitByIP(ctx *Context) string {
+	return "ip:" + limitIP(ctx.Request)
+}
+
+func LimitByUser(ctx *Context) string {
+	if ctx.Current != nil && ctx.Current.Id > 0 {
+		return "user:" + strconv.FormatInt(ctx.Current.Id, 10)
+	}
+
+	return LimitByIP(ctx)
+}
+
+func LimitByRoute(ctx *Context) string {
+	return "route:" + ctx.Method + ":" + ctx.Path
+}
+
+type limitState struct {
+	allowed bool
+	limit int
+	remaining int
+	retry time.Duration
+	reset time.Duration
+}
+
+func limitBucketState(allowed bool, tokens float64, limit int, rate float64) limitState {
+	state := limitState{allowed: allowed, limit: limit, remaining: int(math.Floor(tokens))}
+
+	if !allowed {
+		state.retry = time.Duration((1 - tokens) / rate) * time.Millisecond
+	}
+
+	state.reset = time.Duration((float64(limit) - tokens) / rate) * time.Millisecond
+
+	return state
+}
+
+func limitWindowState(allowed bool, prev int, curr int, elapsed int64, limit int, period int64) limitState {
+	count := float64(prev) * float64(period - elapsed) / float64(period) + float64(curr)
+
+	state := limitState{allowed: allowed, limit: limit, remaining: int(math.Floor(float64(limit) - count))}
+	if state.remaining < 0 {
+		state.remaining = 0
+	}
+
+	state.reset = time.Duration(period - elapsed) * time.Millisecond
+
+	if !allowed {
+		if curr + 1 > limit || prev == 0 {
+			state.retry = state.reset
+		} else {
+			wait := float64(period - elapsed) - float64(limit - 1 - curr) * float64(period) / float64(prev)
+			state.retry = time.Duration(math.Max(wait, 1)) * time.Millisecond
+		}
+	}
+
+	return state
+}
+
+type limitEntry struct {
+	tokens float64
+	last int64
+
+	index int64
+	prev int
+	curr int
+
+	expire int64
+}
+
+type limitMemoryStore struct {
+	mutex sync.Mutex
+	data map[string]*limitEntry
+}
+
+func (this *limitMemoryStore) gc() {
+	ticker := time.NewTicker(time.Minute)
+	for {
+		select {
+		case <- ticker.C:
+			now := time.Now().UnixNano() / 1e6
+
+			this.mutex.Lock()
+			for key, entry := range this.data {
+				if entry.expire < now {
+					delete(this.data, key)
+				}
+			}
+			this.mutex.Unlock()
+		}
+	}
+}
+
+func (this *limitMemoryStore) entry(key string) *limitEntry {
+	entry, ok := this.data[key]
+	if !ok {
+		entry = &limitEntry{tokens: -1}
+		this.data[key] = entry
+	}
+
+	return entry
+}
+
+func (this *limitMemoryStore) bucket(key string, limit int, period time.Duration) limitState {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	now := time.Now().UnixNano() / 1e6
+	rate := float64(limit) / float64(period / time.Millisecond)
+
+	entry := this.entry(key)
+	if entry.tokens < 0 {
+		entry.tokens = float64(limit)
+		entry.last = now
+	}
+
+	entry.tokens = math.Min(float64(limit), entry.tokens + float64(now - entry.last) * rate)
+	entry.last = now
+
+	allowed := false
+	if entry.tokens >= 1 {
+		entry.tokens--
+		allowed = true
+	}
+
+	entry.expire = now + int64(period / time.Millisecond) + 1000
+
+	return limitBucketState(allowed, entry.tokens, limit, rate)
+}
+
+func (this *limitMemoryStore) window(key string, limit int, period time.Duration) limitState {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	now := time.Now().UnixNano() / 1e6
+	size := int64(period / time.Millisecond)
+	index := now / size
+
+	entry := this.entry(key)
+	if entry.index == index - 1 {
+		entry.prev = entry.curr
+		entry.curr = 0
+	} else if entry.index != index {
+		entry.prev = 0
+		entry.curr = 0
+	}
+
+	entry.index = index
+
+	elapsed := now - index * size
+	allowed := false
+
+	if float64(entry.prev) * float64(size - elapsed) / float64(size) + float64(entry.curr) + 1 <= float64(limit) {
+		entry.curr++
+		allowed = true
+	}
+
+	entry.expire = (index + 2) * size
+
+	return limitWindowState(allowed, entry.prev, entry.curr, elapsed, limit, size)
+}
+
+var limitMemory *limitMemoryStore
+var limitMemoryOnce sync.Once
+
+func limitTake(key string, limit int, period time.Duration, bucket bool) limitState {
+	if CONFIG != nil && CONFIG.Redis != nil {
+		now := time.Now().UnixNano() / 1e6
+		size := int64(period / time.Millisecond)
+
+		if bucket {
+			rate := float64(limit) / float64(size)
+
+			result := cache.Eval(limitBucketScript, []string{key}, strconv.FormatFloat(rate, 'f', -1, 64), limit, now)
+			if len(result) == 2 {
+				return limitBucketState(result[0] == "1", FormatFloat64(result[1]), limit, rate)
+			}
+		} else {
+			result := cache.Eval(limitWindowScript, []string{key}, size, limit, now)
+			if len(result) == 4 {
+				return limitWindowState(result[0] == "1", FormatInt(result[1]), FormatInt(result[2]), FormatInt64(result[3]), limit, size)
+			}
+		}
+
+		Logger("limit redis unavailable key:" + key, "error")
+
+		return limitState{allowed: true, limit: limit, remaining: limit}
+	}
+
+	limitMemoryOnce.Do(func() {
+		limitMemory = &limitMemoryStore{data: map[string]*limitEntry{}}
+		go limitMemory.gc()
+	})
+
+	if bucket {
+		return limitMemory.bucket(key, limit, period)
+	}
+
+	return limitMemory.window(key, limit, period)
+}
+
+func limitFilter(name string, limit int, period time.Duration, key LimitKeyFunc, bucket bool) BeforeFilterFunc {
+	if key == nil {
+		key = LimitByIP
+	}
+
+	return func(ctx *Context) bool {
+		state := limitTake("tec:limit:" + name + ":" + key(ctx), limit, period, bucket)
+
+		header := ctx.Response.Header()
+		header.Set("X-RateLimit-Limit", strconv.Itoa(state.limit))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(state.remaining))
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(state.reset.Seconds())), 10))
+
+		if state.allowed {
+			return true
+		}
+
+		header.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(state.retry.Seconds())), 10))
+
+		ctx.Status(http.StatusTooManyRequests)
+		ctx.Json(Result{Code: http.StatusTooManyRequests, Msg: "too many requests"})
+
+		return false
+	}
+}
+
+func TokenBucket(name string, limit int, period time.Duration, key LimitKeyFunc) BeforeFilterFunc {
+	return limitFilter(name, limit, period, key, true)
+}
+
+func SlidingWindow(name string, limit int, period time.Duration, key LimitKeyFunc) BeforeFilterFunc {
+	return limitFilter(name, limit, period, key, false)
+}
+
+func limitRules(config *configOfLimit) BeforeFilterFunc {
+	filters := map[string]BeforeFilterFunc{}
+	prefixes := []string{}
+
+	for path, rule := range config.Rules {
+		fields := strings.Fields(rule)
+		if len(fields) == 0 || !strings.Contains(fields[0], "/") {
+			Logger("limit rule path:" + path + " invalid:" + rule, "error")
+			continue
+		}
+
+		rate := strings.SplitN(fields[0], "/", 2)
+
+		limit := FormatInt(rate[0])
+		seconds := FormatInt(rate[1])
+		if limit < 1 || seconds < 1 {
+			Logger("limit rule path:" + path + " invalid:" + rule, "error")
+			continue
+		}
+
+		key := LimitByIP
+		bucket := false
+
+		for _, field := range fields[1:] {
+			switch strings.ToLower(field) {
+			case "ip":
+				key = LimitByIP
+			case "user":
+				key = LimitByUser
+			case "route":
+				key = LimitByRoute
+			case "bucket":
+				bucket = true
+			case "window":
+				bucket = false
+			}
+		}
+
+		filters[path] = limitFilter(path, limit, time.Duration(seconds) * time.Second, key, bucket)
+
+		if strings.HasSuffix(path, "*") {
+			prefixes = append(prefixes, path)
+		}
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	return func(ctx *Context) bool {
+		if filter, ok := filters[ctx.Path]; ok {
+			return filter(ctx)
+		}
+
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(ctx.Path, prefix[0:len(prefix) - 1]) {
+				return filters[prefix](ctx)
+			}
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,68 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestLimitRulesAfterUserFilters(t *testing.T) {
+	app := New()
+
+	app.Bind("config", func(config *Config) {
+		config.Limit = &configOfLimit{Rules: map[string]string{"/limit/user": "1/60 user"}}
+	})
+
+	app.Start(func(app *App) {
+		app.Before(func(ctx *Context) bool {
+			if id := FormatInt64(ctx.Request.Header.Get("X-User")); id > 0 {
+				ctx.Current = &Current{Id: id}
+			}
+
+			return true
+		})
+	})
+
+	app.init()
+
+	app.Router.GET("/limit/user", func(ctx *Context) {
+		ctx.Text("ok")
+	})
+
+	for i, user := range []int{1, 2, 1} {
+		code := 200
+		if i == 2 {
+			code = 429
+		}
+
+		req := httptest.NewRequest("GET", "/limit/user", nil)
+		req.Header.Set("X-User", strconv.Itoa(user))
+
+		rep := httptest.NewRecorder()
+		app.Handler(rep, req)
+
+		if rep.Code != code {
+			t.Errorf("request %d: expect %d, got %d", i, code, rep.Code)
+		}
+	}
+}
+
+func TestLimitByIPIgnoresForwardedFor(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+
+	if key := LimitByIP(&Context{Request: req}); key != "ip:192.0.2.1" {
+		t.Errorf("untrusted peer: expect ip:192.0.2.1, got %s", key)
+	}
+
+	app.Config.App.Proxy = "192.0.2.0/24"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7, 192.0.2.8")
+
+	if key := LimitByIP(&Context{Request: req}); key != "ip:198.51.100.7" {
+		t.Errorf("trusted proxy: expect ip:198.51.100.7, got %s", key)
+	}
+}
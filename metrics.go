@@ -0,0 +1,35 @@
+package tec
+
+import (
+	"github.com/agilecho/tec/metrics"
+	"strconv"
+	"time"
+)
+
+var httpRequests = metrics.NewCounter("http_requests_total", "Total number of HTTP requests.", "method", "route", "status")
+var httpDuration = metrics.NewHistogram("http_request_duration_seconds", "HTTP request latency in seconds.", nil, "method", "route")
+var httpInflight = metrics.NewGauge("http_requests_in_flight", "Number of HTTP requests being served.")
+
+func httpMetrics(ctx *Context, writer *responseWriter, start time.Time) {
+	route := ctx.route
+	if route == "" {
+		route = "none"
+	}
+
+	httpInflight.Dec()
+	httpRequests.Inc(ctx.Request.Method, route, strconv.Itoa(writer.Status()))
+	httpDuration.Observe(time.Since(start).Seconds(), ctx.Request.Method, route)
+}
+
+func (this *App) metricsRoutes() {
+	if this.Config.App == nil || this.Config.App.Metrics == "" {
+		return
+	}
+
+	this.Router.GET(this.Config.App.Metrics, func(ctx *Context) {
+		ctx.Response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		ctx.writeHeader()
+
+		metrics.Write(ctx.Response)
+	})
+}
@@ -0,0 +1,391 @@
+package metrics
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var Buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(text *strings.Builder)
+}
+
+var registry = map[string]metric{}
+var mutex sync.RWMutex
+
+func register(name string, item metric) metric {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if exist, ok := registry[name]; ok {
+		return exist
+	}
+
+	registry[name] = item
+
+	return item
+}
+
+func escape(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(value)
+}
+
+func format(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	if math.IsInf(value, -1) {
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+type family struct {
+	name string
+	help string
+	kind string
+	labels []string
+}
+
+func (this *family) key(values []string) (string, []string) {
+	tmp := make([]string, len(this.labels))
+	copy(tmp, values)
+
+	return strings.Join(tmp, "\xff"), tmp
+}
+
+func (this *family) header(text *strings.Builder) {
+	text.WriteString("# HELP ")
+	text.WriteString(this.name)
+	text.WriteString(" ")
+	text.WriteString(strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(this.help))
+	text.WriteString("\n# TYPE ")
+	text.WriteString(this.name)
+	text.WriteString(" ")
+	text.WriteString(this.kind)
+	text.WriteString("\n")
+}
+
+func (this *family) sample(text *strings.Builder, suffix string, values []string, extra string, value float64) {
+	text.WriteString(this.name)
+	text.WriteString(suffix)
+
+	if len(this.labels) > 0 || extra != "" {
+		text.WriteString("{")
+
+		for i, label := range this.labels {
+			if i > 0 {
+				text.WriteString(",")
+			}
+
+			text.WriteString(label)
+			text.WriteString("=\"")
+			text.WriteString(escape(values[i]))
+			text.WriteString("\"")
+		}
+
+		if extra != "" {
+			if len(this.labels) > 0 {
+				text.WriteString(",")
+			}
+
+			text.WriteString(extra)
+		}
+
+		text.WriteString("}")
+	}
+
+	text.WriteString(" ")
+	text.WriteString(format(value))
+	text.WriteString("\n")
+}
+
+type value struct {
+	labels []string
+	value float64
+}
+
+type Counter struct {
+	family
+	mutex sync.Mutex
+	values map[string]*value
+	fun func() float64
+}
+
+func (this *Counter) Add(num float64, labels ...string) {
+	if num < 0 {
+		return
+	}
+
+	key, tmp := this.key(labels)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	item, ok := this.values[key]
+	if !ok {
+		item = &value{labels: tmp}
+		this.values[key] = item
+	}
+
+	item.value += num
+}
+
+func (this *Counter) Inc(labels ...string) {
+	this.Add(1, labels...)
+}
+
+func (this *Counter) write(text *strings.Builder) {
+	this.header(text)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.fun != nil {
+		this.sample(text, "", nil, "", this.fun())
+		return
+	}
+
+	keys := []string{}
+	for key, _ := range this.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		this.sample(text, "", this.values[key].labels, "", this.values[key].value)
+	}
+}
+
+type Gauge struct {
+	family
+	mutex sync.Mutex
+	values map[string]*value
+	fun func() float64
+}
+
+func (this *Gauge) Set(num float64, labels ...string) {
+	key, tmp := this.key(labels)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	item, ok := this.values[key]
+	if !ok {
+		item = &value{labels: tmp}
+		this.values[key] = item
+	}
+
+	item.value = num
+}
+
+func (this *Gauge) Add(num float64, labels ...string) {
+	key, tmp := this.key(labels)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	item, ok := this.values[key]
+	if !ok {
+		item = &value{labels: tmp}
+		this.values[key] = item
+	}
+
+	item.value += num
+}
+
+func (this *Gauge) Inc(labels ...string) {
+	this.Add(1, labels...)
+}
+
+func (this *Gauge) Dec(labels ...string) {
+	this.Add(-1, labels...)
+}
+
+func (this *Gauge) write(text *strings.Builder) {
+	this.header(text)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.fun != nil {
+		this.sample(text, "", nil, "", this.fun())
+		return
+	}
+
+	keys := []string{}
+	for key, _ := range this.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		this.sample(text, "", this.values[key].labels, "", this.values[key].value)
+	}
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	sum float64
+	count uint64
+}
+
+type Histogram struct {
+	family
+	buckets []float64
+	mutex sync.Mutex
+	values map[string]*histogramValue
+}
+
+func (this *Histogram) Observe(num float64, labels ...string) {
+	key, tmp := this.key(labels)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	item, ok := this.values[key]
+	if !ok {
+		item = &histogramValue{labels: tmp, counts: make([]uint64, len(this.buckets))}
+		this.values[key] = item
+	}
+
+	for i, bucket := range this.buckets {
+		if num <= bucket {
+			item.counts[i]++
+		}
+	}
+
+	item.sum += num
+	item.count++
+}
+
+func (this *Histogram) write(text *strings.Builder) {
+	this.header(text)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	keys := []string{}
+	for key, _ := range this.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		item := this.values[key]
+
+		for i, bucket := range this.buckets {
+			this.sample(text, "_bucket", item.labels, "le=\"" + format(bucket) + "\"", float64(item.counts[i]))
+		}
+
+		this.sample(text, "_bucket", item.labels, "le=\"+Inf\"", float64(item.count))
+		this.sample(text, "_sum", item.labels, "", item.sum)
+		this.sample(text, "_count", item.labels, "", float64(item.count))
+	}
+}
+
+func initial(labels []string) map[string]*value {
+	values := map[string]*value{}
+	if len(labels) == 0 {
+		values[""] = &value{labels: []string{}}
+	}
+
+	return values
+}
+
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return register(name, &Counter{
+		family: family{name: name, help: help, kind: "counter", labels: labels},
+		values: initial(labels),
+	}).(*Counter)
+}
+
+func NewCounterFunc(name string, help string, fun func() float64) *Counter {
+	counter := register(name, &Counter{
+		family: family{name: name, help: help, kind: "counter"},
+		values: map[string]*value{},
+	}).(*Counter)
+
+	counter.mutex.Lock()
+	counter.fun = fun
+	counter.mutex.Unlock()
+
+	return counter
+}
+
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return register(name, &Gauge{
+		family: family{name: name, help: help, kind: "gauge", labels: labels},
+		values: initial(labels),
+	}).(*Gauge)
+}
+
+func NewGaugeFunc(name string, help string, fun func() float64) *Gauge {
+	gauge := register(name, &Gauge{
+		family: family{name: name, help: help, kind: "gauge"},
+		values: map[string]*value{},
+	}).(*Gauge)
+
+	gauge.mutex.Lock()
+	gauge.fun = fun
+	gauge.mutex.Unlock()
+
+	return gauge
+}
+
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = Buckets
+	}
+
+	tmp := make([]float64, len(buckets))
+	copy(tmp, buckets)
+	sort.Float64s(tmp)
+
+	return register(name, &Histogram{
+		family: family{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: tmp,
+		values: map[string]*histogramValue{},
+	}).(*Histogram)
+}
+
+func Text() string {
+	mutex.RLock()
+
+	names := []string{}
+	for name, _ := range registry {
+		names = append(names, name)
+	}
+
+	items := make([]metric, 0, len(names))
+	sort.Strings(names)
+
+	for _, name := range names {
+		items = append(items, registry[name])
+	}
+
+	mutex.RUnlock()
+
+	text := strings.Builder{}
+	for _, item := range items {
+		item.write(&text)
+	}
+
+	return text.String()
+}
+
+func Write(writer io.Writer) error {
+	_, err := io.WriteString(writer, Text())
+	return err
+}
@@ -1,6 +1,8 @@
 package mongo
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/agilecho/tec/mongo/mgo"
 	"os"
@@ -248,6 +250,32 @@ func (this *Mongo) DropCollection(db string, collection string) bool {
 	return true
 }
 
+func (this *Mongo) Check(ctx context.Context) error {
+	if this.session == nil {
+		return errors.New("mongo session not open")
+	}
+
+	session := this.session.Copy()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		session.SetSyncTimeout(time.Until(deadline))
+		session.SetSocketTimeout(time.Until(deadline))
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		defer session.Close()
+		result <- session.Ping()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (this *Mongo) Close() {
 	if this.session != nil {
 		this.session.Close()
@@ -296,6 +324,10 @@ func DropCollection(db string, collection string) bool {
 	return handler.DropCollection(db, collection)
 }
 
+func Check(ctx context.Context) error {
+	return handler.Check(ctx)
+}
+
 func Close() {
 	handler.Close()
 }
\ No newline at end of file
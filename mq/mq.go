@@ -1,20 +1,27 @@
 package mq
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"github.com/agilecho/tec/metrics"
 	"github.com/agilecho/tec/mq/amqp"
 	"io"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var publishTotal = metrics.NewCounter("mq_published_total", "Total number of messages published.", "queue", "status")
+var consumeTotal = metrics.NewCounter("mq_consumed_total", "Total number of messages delivered to consumers.", "queue")
+
 type Message struct {
 	delivery amqp.Delivery
 	Id string
@@ -83,10 +90,13 @@ func (this *Queue) Put(data string) interface{} {
 	})
 
 	if err != nil {
+		publishTotal.Inc(this.name, "error")
 		this.rabbitmq.logger("mq.Put error:" + err.Error())
 		return false
 	}
 
+	publishTotal.Inc(this.name, "ok")
+
 	return true
 }
 
@@ -95,13 +105,35 @@ func (this *Queue) Reserve(fun ReserveMessageFunc) {
 		return
 	}
 
-	deliveries, err := this.rabbitmq.channel.Consume(this.queue.Name, "", false, false, false, true, nil)
+	tag := this.uuid()
+
+	this.rabbitmq.consumerMu.Lock()
+	if atomic.LoadInt32(&this.rabbitmq.stopping) == 1 {
+		this.rabbitmq.consumerMu.Unlock()
+		return
+	}
+
+	deliveries, err := this.rabbitmq.channel.Consume(this.queue.Name, tag, false, false, false, true, nil)
 	if err != nil {
+		this.rabbitmq.consumerMu.Unlock()
 		this.rabbitmq.logger("mq.Reserve error:" + err.Error())
 		return
 	}
 
+	this.rabbitmq.consumerWG.Add(1)
+	this.rabbitmq.consumers = append(this.rabbitmq.consumers, tag)
+	this.rabbitmq.consumerMu.Unlock()
+
+	defer this.rabbitmq.consumerWG.Done()
+
 	for delivery := range deliveries {
+		if atomic.LoadInt32(&this.rabbitmq.stopping) == 1 {
+			delivery.Nack(false, true)
+			break
+		}
+
+		consumeTotal.Inc(this.name)
+
 		fun(this, &Message{
 			delivery: delivery,
 			Id: delivery.MessageId,
@@ -126,6 +158,11 @@ type RabbitMQ struct {
 	channel *amqp.Channel
 	queues []*Queue
 	mu sync.RWMutex
+
+	consumers []string
+	consumerMu sync.Mutex
+	consumerWG sync.WaitGroup
+	stopping int32
 }
 
 func (this *RabbitMQ) microtime() string {
@@ -175,6 +212,18 @@ func (this *RabbitMQ) Connect() {
 	}
 }
 
+func (this *RabbitMQ) Check() error {
+	if this.handle == nil || this.handle.IsClosed() {
+		return errors.New("mq connection closed")
+	}
+
+	if this.channel == nil {
+		return errors.New("mq channel not open")
+	}
+
+	return nil
+}
+
 func (this *RabbitMQ) Close() {
 	if this.handle == nil {
 		return
@@ -191,6 +240,35 @@ func (this *RabbitMQ) Close() {
 	}
 }
 
+func (this *RabbitMQ) Stop(ctx context.Context) {
+	this.consumerMu.Lock()
+	atomic.StoreInt32(&this.stopping, 1)
+
+	if this.channel != nil {
+		for _, tag := range this.consumers {
+			err := this.channel.Cancel(tag, false)
+			if err != nil {
+				this.logger("mq.Stop Cancel error:" + err.Error())
+			}
+		}
+	}
+
+	this.consumers = nil
+	this.consumerMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		this.consumerWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		this.logger("mq.Stop timeout waiting for consumers")
+	}
+}
+
 func (this *RabbitMQ) DirectQueue(name string) *Queue {
 	queue := &Queue{
 		rabbitmq: this,
@@ -272,6 +350,14 @@ func FanoutQueue(name string) *Queue {
 	return handler.FanoutQueue(name)
 }
 
+func Stop(ctx context.Context) {
+	handler.Stop(ctx)
+}
+
+func Check() error {
+	return handler.Check()
+}
+
 func Close() {
 	handler.Close()
 }
@@ -0,0 +1,93 @@
+package tec
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+type ErrorFunc func(ctx *Context, err interface{}, stack string)
+
+func (this *App) Error(fun ErrorFunc) {
+	this.errorFunc = &fun
+}
+
+func (this *App) Go(fun func()) {
+	go func() {
+		defer this.recover(nil)
+		fun()
+	}()
+}
+
+func (this *App) report(ctx *Context, err interface{}, stack string) {
+	if ctx == nil {
+		Logger("app panic:" + fmt.Sprint(err) + "\r\n" + stack, "error")
+	} else {
+		ctx.Logger("app panic path:" + ctx.Path + " method:" + ctx.Method + " error:" + fmt.Sprint(err) + "\r\n" + stack, "error")
+	}
+
+	if this.errorFunc != nil {
+		defer Exception("app error hook ")
+		(*this.errorFunc)(ctx, err, stack)
+	}
+}
+
+func (this *App) recover(ctx *Context) {
+	if err := recover(); err != nil {
+		this.report(ctx, err, string(debug.Stack()))
+	}
+}
+
+func (this *App) serverError(ctx *Context) {
+	err := recover()
+	if err == nil {
+		return
+	}
+
+	if err == http.ErrAbortHandler {
+		ctx.Close()
+		panic(err)
+	}
+
+	this.report(ctx, err, string(debug.Stack()))
+
+	defer ctx.Close()
+	defer Exception("app serverError ")
+
+	if compress, ok := ctx.Response.(*compressWriter); ok {
+		compress.Reset()
+	}
+
+	if ctx.writer != nil && ctx.writer.status != 0 {
+		return
+	}
+
+	message := "internal server error"
+	if this.Config.App != nil && this.Config.App.Debug {
+		message = fmt.Sprint(err)
+	}
+
+	ctx.Status(http.StatusInternalServerError)
+
+	if CONFIG.Template != nil && CONFIG.Template.Error != "" && !ctx.IsAjax {
+		ctx.Render(CONFIG.Template.Error, map[string]interface{}{"message": message, "request_id": ctx.RequestId})
+	} else {
+		ctx.Json(Result{Code: http.StatusInternalServerError, Msg: message, Data: ctx.RequestId})
+	}
+}
+
+func (this *Context) Go(fun func()) {
+	if this.app == nil {
+		go func() {
+			defer Exception("context.Go ")
+			fun()
+		}()
+
+		return
+	}
+
+	go func() {
+		defer this.app.recover(this)
+		fun()
+	}()
+}
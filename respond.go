@@ -0,0 +1,193 @@
+package tec
+
+import (
+	"encoding/xml"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var respondTypes = map[string]string{
+	"json": "application/json",
+	"xml": "application/xml",
+	"html": "text/html",
+	"text": "text/plain",
+}
+
+var respondSuffixes = map[string]string{
+	".json": "json",
+	".xml": "xml",
+	".html": "html",
+	".htm": "html",
+	".txt": "text",
+}
+
+func respondSuffix(uri string) (string, string) {
+	if format, ok := respondSuffixes[strings.ToLower(path.Ext(uri))]; ok {
+		return uri[0:len(uri) - len(path.Ext(uri))], format
+	}
+
+	return uri, ""
+}
+
+func respondFormat(value string) string {
+	value = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "."))
+
+	switch value {
+	case "json", "xml", "html", "text":
+		return value
+	case "htm":
+		return "html"
+	case "txt", "plain":
+		return "text"
+	}
+
+	return ""
+}
+
+func respondNegotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+
+	for _, offer := range offers {
+		mime := respondTypes[offer]
+		q, level := 0.0, -1
+
+		for _, item := range strings.Split(accept, ",") {
+			fields := strings.Split(item, ";")
+			name := strings.ToLower(strings.TrimSpace(fields[0]))
+
+			value := 1.0
+			for _, field := range fields[1:] {
+				field = strings.TrimSpace(field)
+				if strings.HasPrefix(field, "q=") {
+					value, _ = strconv.ParseFloat(field[2:], 64)
+				}
+			}
+
+			matched := -1
+			if name == mime {
+				matched = 2
+			} else if strings.HasSuffix(name, "/*") && strings.HasPrefix(mime, name[0:len(name) - 1]) {
+				matched = 1
+			} else if name == "*" || name == "*/*" {
+				matched = 0
+			}
+
+			if matched > level {
+				q, level = value, matched
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+func (this *Context) Respond(data interface{}, args ...string) {
+	offers := []string{"json", "xml"}
+	if len(args) > 0 && args[0] != "" {
+		offers = append(offers, "html")
+	}
+
+	offers = append(offers, "text")
+
+	this.Response.Header().Add("Vary", "Accept")
+
+	format := this.format
+	if format == "" {
+		format = respondFormat(this.Param["format"])
+	}
+
+	explicit := format != ""
+
+	var content []byte
+	for {
+		if explicit {
+			if !InArray(format, offers) {
+				format = ""
+			}
+		} else {
+			format = respondNegotiate(this.Accept, offers)
+		}
+
+		if format != "xml" {
+			break
+		}
+
+		result, err := xml.Marshal(data)
+		if err == nil {
+			content = result
+			break
+		}
+
+		this.Logger("context.Respond xml error:" + err.Error(), "error")
+
+		tmp := []string{}
+		for _, offer := range offers {
+			if offer != "xml" {
+				tmp = append(tmp, offer)
+			}
+		}
+
+		offers = tmp
+	}
+
+	if format == "" {
+		types := []string{}
+		for _, offer := range offers {
+			types = append(types, respondTypes[offer])
+		}
+
+		this.Response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		this.Status(http.StatusNotAcceptable)
+		this.writeHeader()
+		this.Response.Write([]byte("not acceptable, available:" + strings.Join(types, ",")))
+		return
+	}
+
+	if format == "xml" {
+		this.invokeAfter("XML", data)
+		this.writeXML(content)
+		return
+	}
+
+	this.respond(format, data, args...)
+}
+
+func (this *Context) respond(format string, data interface{}, args ...string) {
+	switch format {
+	case "html":
+		value, ok := data.(map[string]interface{})
+		if !ok {
+			value = map[string]interface{}{"data": data}
+		}
+
+		this.Render(args[0], value)
+	case "text":
+		text, ok := data.(string)
+		if !ok {
+			text = JsonEncode(data)
+		}
+
+		this.invokeAfter("Text", text)
+
+		this.Response.Header().Set("Content-Type", "text/plain")
+		this.Response.Header().Set("Charset", "UTF-8")
+		this.writeHeader()
+
+		_, err := this.Response.Write([]byte(text))
+		if err != nil {
+			this.Logger("context.Respond error:" + err.Error(), "error")
+		}
+	default:
+		this.Json(data)
+	}
+}
@@ -0,0 +1,107 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondSuffix(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.GET("/orders", func(ctx *Context) {
+		ctx.Respond(map[string]string{"id": "1"})
+	})
+
+	app.Router.GET("/files/:name", func(ctx *Context) {
+		ctx.Text(ctx.PathParam["name"])
+	})
+
+	app.Router.GET("/raw/*path", func(ctx *Context) {
+		ctx.Text(ctx.PathParam["path"])
+	})
+
+	cases := map[string]string{
+		"/files/report.txt": "report.txt",
+		"/raw/a/b/data.json": "a/b/data.json",
+		"/orders.json": `{"id":"1"}`,
+	}
+
+	for path, expect := range cases {
+		rep := httptest.NewRecorder()
+		app.Handler(rep, httptest.NewRequest("GET", path, nil))
+
+		if rep.Body.String() != expect {
+			t.Errorf("%s: expect %q, got %q", path, expect, rep.Body.String())
+		}
+	}
+}
+
+func TestRespondXMLFallback(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.GET("/data", func(ctx *Context) {
+		ctx.Respond(Result{Code: 200, Data: map[string]int{"a": 1}})
+	})
+
+	cases := []struct {
+		accept string
+		code int
+		body string
+	}{
+		{"application/xml, application/json;q=0.5", 200, `{"code":200,"msg":"","data":{"a":1}}`},
+		{"application/xml", 406, ""},
+	}
+
+	for _, item := range cases {
+		req := httptest.NewRequest("GET", "/data", nil)
+		req.Header.Set("Accept", item.accept)
+
+		rep := httptest.NewRecorder()
+		app.Handler(rep, req)
+
+		if rep.Code != item.code || (item.body != "" && rep.Body.String() != item.body) {
+			t.Errorf("%s: got %d %q", item.accept, rep.Code, rep.Body.String())
+		}
+	}
+}
+
+func TestRespondUnknownFormat(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.GET("/data", func(ctx *Context) {
+		ctx.Respond(map[string]string{"id": "1"})
+	})
+
+	for path, code := range map[string]int{"/data?format=compact": 200, "/data?format=text": 200, "/data?format=html": 406} {
+		rep := httptest.NewRecorder()
+		app.Handler(rep, httptest.NewRequest("GET", path, nil))
+
+		if rep.Code != code {
+			t.Errorf("%s: expect %d, got %d %q", path, code, rep.Code, rep.Body.String())
+		}
+	}
+}
+
+func TestXMLAfterOnce(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	calls := 0
+	app.After(func(ctx *Context, method string, data interface{}) {
+		calls++
+	})
+
+	app.Router.GET("/xml", func(ctx *Context) {
+		ctx.XML(map[string]int{"a": 1})
+	})
+
+	rep := httptest.NewRecorder()
+	app.Handler(rep, httptest.NewRequest("GET", "/xml", nil))
+
+	if rep.Code != 500 || calls != 1 {
+		t.Errorf("expect 500 with one after call, got %d %d", rep.Code, calls)
+	}
+}
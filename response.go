@@ -0,0 +1,69 @@
+package tec
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+type responseWriter struct {
+	http.ResponseWriter
+
+	status int
+	size int64
+}
+
+func (this *responseWriter) WriteHeader(code int) {
+	if this.status == 0 {
+		this.status = code
+	}
+
+	this.ResponseWriter.WriteHeader(code)
+}
+
+func (this *responseWriter) Write(data []byte) (int, error) {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+
+	size, err := this.ResponseWriter.Write(data)
+	this.size += int64(size)
+
+	return size, err
+}
+
+func (this *responseWriter) Flush() {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+
+	if f, ok := this.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (this *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := this.ResponseWriter.(http.Hijacker); ok {
+		this.status = http.StatusSwitchingProtocols
+		return h.Hijack()
+	}
+
+	return nil, nil, errors.New("response does not implement http.Hijacker")
+}
+
+func (this *responseWriter) Unwrap() http.ResponseWriter {
+	return this.ResponseWriter
+}
+
+func (this *responseWriter) Status() int {
+	if this.status == 0 {
+		return http.StatusOK
+	}
+
+	return this.status
+}
+
+func (this *responseWriter) Size() int64 {
+	return this.size
+}
@@ -0,0 +1,702 @@
+package tec
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const resumableVersion = "1.0.0"
+
+var resumableLocks sync.Map
+
+type ResumableOption struct {
+	UploadOption
+
+	Temp string
+	Expire time.Duration
+	Complete func(ctx *Context, file *UploadFile) error
+}
+
+type resumableInfo struct {
+	Id string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Length int64 `json:"length"`
+	Offset int64 `json:"offset"`
+	Checksum string `json:"checksum,omitempty"`
+	Metadata string `json:"metadata,omitempty"`
+	Expires int64 `json:"expires"`
+	File *UploadFile `json:"file,omitempty"`
+}
+
+type resumable struct {
+	path string
+	option ResumableOption
+}
+
+func resumableHash(algorithm string) hash.Hash {
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	}
+
+	return nil
+}
+
+func resumableChecksum(value string) (string, []byte, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil, nil
+	}
+
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ' ' || r == ':'
+	})
+
+	if len(fields) != 2 || resumableHash(fields[0]) == nil {
+		return "", nil, errors.New("checksum invalid:" + value)
+	}
+
+	sum, err := hex.DecodeString(fields[1])
+	if err != nil || len(sum) != resumableHash(fields[0]).Size() {
+		sum, err = base64.StdEncoding.DecodeString(fields[1])
+	}
+
+	if err != nil || len(sum) != resumableHash(fields[0]).Size() {
+		return "", nil, errors.New("checksum invalid:" + value)
+	}
+
+	return strings.ToLower(fields[0]), sum, nil
+}
+
+func resumableMetadata(value string) map[string]string {
+	data := map[string]string{}
+
+	for _, item := range strings.Split(value, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+
+		data[fields[0]] = ""
+		if len(fields) > 1 {
+			if decoded, err := base64.StdEncoding.DecodeString(fields[1]); err == nil {
+				data[fields[0]] = string(decoded)
+			}
+		}
+	}
+
+	return data
+}
+
+func resumableCopy(source string, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY | os.O_CREATE | os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func (this *resumable) lock(id string) *sync.Mutex {
+	mutex, _ := resumableLocks.LoadOrStore(this.option.Temp + "/" + id, &sync.Mutex{})
+	return mutex.(*sync.Mutex)
+}
+
+func (this *resumable) file(id string, ext string) string {
+	return filepath.Join(this.option.Temp, id + ext)
+}
+
+func resumableId(id string) bool {
+	return len(id) == 32 && strings.Trim(id, "0123456789abcdef") == ""
+}
+
+func (this *resumable) load(id string) *resumableInfo {
+	if !resumableId(id) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(this.file(id, ".json"))
+	if err != nil {
+		return nil
+	}
+
+	info := &resumableInfo{}
+	if json.Unmarshal(data, info) != nil {
+		return nil
+	}
+
+	if info.File == nil && info.Expires < time.Now().Unix() {
+		this.remove(id)
+		return nil
+	}
+
+	return info
+}
+
+func (this *resumable) save(info *resumableInfo) error {
+	data, _ := json.Marshal(info)
+
+	tmp := this.file(info.Id, ".json.tmp")
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, this.file(info.Id, ".json"))
+}
+
+func (this *resumable) remove(id string) {
+	os.Remove(this.file(id, ".bin"))
+	os.Remove(this.file(id, ".json"))
+}
+
+func (this *resumable) collect() {
+	files, _ := ioutil.ReadDir(this.option.Temp)
+	for _, file := range files {
+		name := file.Name()
+		id := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ".bin"), ".json.tmp"), ".json")
+		if !resumableId(id) {
+			continue
+		}
+
+		mutex := this.lock(id)
+		mutex.Lock()
+
+		if strings.HasSuffix(name, ".json") {
+			info := this.load(id)
+			if info != nil && info.File != nil && file.ModTime().Add(this.option.Expire).Before(time.Now()) {
+				this.remove(id)
+				info = nil
+			}
+
+			if info == nil {
+				resumableLocks.Delete(this.option.Temp + "/" + id)
+			}
+		} else if !IsFile(this.file(id, ".json")) && file.ModTime().Add(this.option.Expire).Before(time.Now()) {
+			os.Remove(filepath.Join(this.option.Temp, name))
+			resumableLocks.Delete(this.option.Temp + "/" + id)
+		}
+
+		mutex.Unlock()
+	}
+}
+
+func (this *App) resumableCollect() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <- ticker.C:
+			this.Router.table.mutex.Lock()
+			handlers := append([]*resumable{}, this.Router.table.resumables...)
+			this.Router.table.mutex.Unlock()
+
+			for _, handler := range handlers {
+				handler.collect()
+			}
+		case <- this.done:
+			return
+		}
+	}
+}
+
+func (this *resumable) header(ctx *Context, info *resumableInfo) {
+	header := ctx.Response.Header()
+	header.Set("Tus-Resumable", resumableVersion)
+	header.Set("Cache-Control", "no-store")
+
+	if info != nil {
+		header.Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		header.Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+		header.Set("Upload-Expires", time.Unix(info.Expires, 0).UTC().Format(http.TimeFormat))
+
+		if info.Metadata != "" {
+			header.Set("Upload-Metadata", info.Metadata)
+		}
+	}
+}
+
+func (this *resumable) fail(ctx *Context, code int, msg string) {
+	this.header(ctx, nil)
+
+	ctx.Status(code)
+	ctx.Json(Result{Code: code, Msg: msg})
+}
+
+func (this *resumable) options(ctx *Context) {
+	header := ctx.Response.Header()
+	header.Set("Tus-Resumable", resumableVersion)
+	header.Set("Tus-Version", resumableVersion)
+	header.Set("Tus-Max-Size", strconv.FormatInt(this.option.Max, 10))
+	header.Set("Tus-Extension", "creation,creation-with-upload,checksum,expiration,termination")
+	header.Set("Tus-Checksum-Algorithm", "md5,sha1,sha256")
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (this *resumable) create(ctx *Context) {
+	value := ctx.Request.Header.Get("Upload-Length")
+	if value == "" {
+		value = ctx.Param["length"]
+	}
+
+	length, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || length <= 0 {
+		this.fail(ctx, http.StatusBadRequest, "upload length invalid:" + value)
+		return
+	}
+
+	if length > this.option.Max {
+		this.fail(ctx, http.StatusRequestEntityTooLarge, "upload file too large limit:" + strconv.FormatInt(this.option.Max, 10))
+		return
+	}
+
+	metadata := resumableMetadata(ctx.Request.Header.Get("Upload-Metadata"))
+	for _, key := range []string{"filename", "filetype", "checksum"} {
+		if metadata[key] == "" && ctx.Param[key] != "" {
+			metadata[key] = ctx.Param[key]
+		}
+	}
+
+	if metadata["filename"] == "" {
+		metadata["filename"] = metadata["name"]
+	}
+
+	if _, err := uploadExt(metadata["filename"], this.option.UploadOption); err != nil {
+		this.fail(ctx, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	if _, _, err := resumableChecksum(metadata["checksum"]); err != nil {
+		this.fail(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !IsDir(this.option.Temp) {
+		if err := os.MkdirAll(this.option.Temp, os.ModePerm); err != nil {
+			ctx.Logger("resumable MkdirAll error:" + err.Error(), "error")
+			this.fail(ctx, http.StatusInternalServerError, "upload create failed")
+			return
+		}
+	}
+
+	info := &resumableInfo{
+		Id: uploadName(),
+		Name: filepath.Base(strings.Replace(metadata["filename"], "\\", "/", -1)),
+		Type: metadata["filetype"],
+		Length: length,
+		Checksum: metadata["checksum"],
+		Metadata: ctx.Request.Header.Get("Upload-Metadata"),
+		Expires: time.Now().Add(this.option.Expire).Unix(),
+	}
+
+	file, err := os.OpenFile(this.file(info.Id, ".bin"), os.O_WRONLY | os.O_CREATE | os.O_EXCL, 0644)
+	if err == nil {
+		file.Close()
+		err = this.save(info)
+	}
+
+	if err != nil {
+		ctx.Logger("resumable create error:" + err.Error(), "error")
+		this.remove(info.Id)
+		this.fail(ctx, http.StatusInternalServerError, "upload create failed")
+		return
+	}
+
+	location := strings.TrimRight(this.path, "/") + "/" + info.Id
+	if CONFIG.Gateway != nil && CONFIG.Gateway.Enable {
+		location = "/" + CONFIG.App.Name + location
+	}
+
+	ctx.Response.Header().Set("Location", location)
+
+	if strings.HasPrefix(ctx.Request.Header.Get("Content-Type"), "application/offset+octet-stream") && ctx.Request.ContentLength != 0 {
+		mutex := this.lock(info.Id)
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if code, err := this.write(ctx, info); err != nil {
+			this.fail(ctx, code, err.Error())
+			return
+		}
+	}
+
+	this.header(ctx, info)
+
+	ctx.Status(http.StatusCreated)
+	ctx.Json(Result{Code: http.StatusCreated, Msg: "ok", Data: info})
+}
+
+func (this *resumable) head(ctx *Context) {
+	info := this.load(ctx.PathParam["id"])
+	if info == nil {
+		this.fail(ctx, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	this.header(ctx, info)
+
+	ctx.Status(http.StatusOK)
+}
+
+func (this *resumable) status(ctx *Context) {
+	info := this.load(ctx.PathParam["id"])
+	if info == nil {
+		this.fail(ctx, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	this.header(ctx, info)
+
+	ctx.Json(Result{Code: http.StatusOK, Msg: "ok", Data: info})
+}
+
+func (this *resumable) write(ctx *Context, info *resumableInfo) (int, error) {
+	if value := ctx.Request.Header.Get("Upload-Offset"); value != "" || ctx.Param["offset"] != "" {
+		if value == "" {
+			value = ctx.Param["offset"]
+		}
+
+		offset, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || offset != info.Offset {
+			return http.StatusConflict, errors.New("upload offset mismatch:" + value + " current:" + strconv.FormatInt(info.Offset, 10))
+		}
+	} else if ctx.Request.Method != "POST" {
+		return http.StatusBadRequest, errors.New("upload offset required")
+	}
+
+	checksum := ctx.Request.Header.Get("Upload-Checksum")
+	if checksum == "" {
+		checksum = ctx.Param["checksum"]
+	}
+
+	algorithm, sum, err := resumableChecksum(checksum)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	file, err := os.OpenFile(this.file(info.Id, ".bin"), os.O_WRONLY, 0644)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	defer file.Close()
+
+	if _, err = file.Seek(info.Offset, io.SeekStart); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	var writer io.Writer = file
+	var digest hash.Hash
+	if algorithm != "" {
+		digest = resumableHash(algorithm)
+		writer = io.MultiWriter(file, digest)
+	}
+
+	size, err := io.Copy(writer, io.LimitReader(ctx.Request.Body, info.Length - info.Offset + 1))
+
+	code := 0
+	if err == nil && info.Offset + size > info.Length {
+		code, err = http.StatusRequestEntityTooLarge, errors.New("upload exceeds length:" + strconv.FormatInt(info.Length, 10))
+	} else if err == nil && digest != nil && !bytes.Equal(digest.Sum(nil), sum) {
+		code, err = 460, errors.New("upload checksum mismatch")
+	}
+
+	if code != 0 || (err != nil && digest != nil) {
+		file.Truncate(info.Offset)
+
+		if code == 0 {
+			code = http.StatusBadRequest
+		}
+
+		return code, err
+	}
+
+	info.Offset += size
+	info.Expires = time.Now().Add(this.option.Expire).Unix()
+
+	if err := this.save(info); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if info.Offset == info.Length {
+		return this.assemble(ctx, info)
+	}
+
+	return 0, nil
+}
+
+func (this *resumable) patch(ctx *Context) {
+	id := ctx.PathParam["id"]
+
+	mutex := this.lock(id)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	info := this.load(id)
+	if info == nil {
+		this.fail(ctx, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	if info.File != nil || info.Offset == info.Length {
+		this.fail(ctx, http.StatusConflict, "upload already complete")
+		return
+	}
+
+	contentType := ctx.Request.Header.Get("Content-Type")
+	if ctx.Request.Method == "PATCH" && !strings.HasPrefix(contentType, "application/offset+octet-stream") && !strings.HasPrefix(contentType, "application/octet-stream") {
+		this.fail(ctx, http.StatusUnsupportedMediaType, "content type must be application/offset+octet-stream")
+		return
+	}
+
+	if code, err := this.write(ctx, info); err != nil {
+		this.fail(ctx, code, err.Error())
+		return
+	}
+
+	this.header(ctx, info)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (this *resumable) assemble(ctx *Context, info *resumableInfo) (int, error) {
+	source := this.file(info.Id, ".bin")
+
+	fail := func(code int, err error) (int, error) {
+		this.remove(info.Id)
+		return code, err
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	algorithm, sum, _ := resumableChecksum(info.Checksum)
+
+	digest := sha256.New()
+	writers := []io.Writer{digest}
+
+	var verify hash.Hash
+	if algorithm != "" {
+		verify = resumableHash(algorithm)
+		writers = append(writers, verify)
+	}
+
+	size, err := io.Copy(io.MultiWriter(writers...), file)
+	file.Close()
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if size != info.Length {
+		return fail(http.StatusUnprocessableEntity, errors.New("upload size mismatch:" + strconv.FormatInt(size, 10)))
+	}
+
+	if verify != nil && !bytes.Equal(verify.Sum(nil), sum) {
+		return fail(460, errors.New("upload file checksum mismatch"))
+	}
+
+	ext, err := uploadExt(info.Name, this.option.UploadOption)
+	if err != nil {
+		return fail(http.StatusUnsupportedMediaType, err)
+	}
+
+	file, err = os.Open(source)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	ext, mime, err := uploadType(info.Name, ext, file, this.option.UploadOption)
+	if err != nil {
+		file.Close()
+		return fail(http.StatusUnsupportedMediaType, err)
+	}
+
+	result := &UploadFile{Field: "resumable", Name: info.Name, Type: mime, Size: size, Hash: hex.EncodeToString(digest.Sum(nil))}
+
+	if strings.HasPrefix(mime, "image/") || IsImage(info.Name) {
+		file.Seek(0, io.SeekStart)
+
+		if result.Width, result.Height, ext, err = uploadImage(file, info.Name, mime, this.option.UploadOption); err != nil {
+			file.Close()
+			return fail(http.StatusUnprocessableEntity, err)
+		}
+	}
+
+	file.Close()
+
+	result.Ext = ext
+
+	dir := strings.TrimRight(filepath.ToSlash(filepath.Clean("/" + this.option.Path)), "/") + time.Now().Format("/2006/01/02")
+
+	path, err := uploadPath(dir)
+	if err != nil {
+		return fail(http.StatusInternalServerError, err)
+	}
+
+	if !IsDir(path) {
+		if err = os.MkdirAll(path, os.ModePerm); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	result.Path = dir + "/" + info.Id + ext
+	target := filepath.Join(path, info.Id + ext)
+
+	if err = os.Rename(source, target); err != nil {
+		if err = resumableCopy(source, target); err != nil {
+			os.Remove(target)
+			return http.StatusInternalServerError, err
+		}
+
+		os.Remove(source)
+	}
+
+	if this.option.Complete != nil {
+		if err = this.option.Complete(ctx, result); err != nil {
+			os.Remove(target)
+			return fail(http.StatusUnprocessableEntity, err)
+		}
+	}
+
+	info.File = result
+
+	if err = this.save(info); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return 0, nil
+}
+
+func (this *resumable) complete(ctx *Context) {
+	id := ctx.PathParam["id"]
+
+	mutex := this.lock(id)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	info := this.load(id)
+	if info == nil {
+		this.fail(ctx, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	if info.File == nil && info.Offset < info.Length {
+		this.header(ctx, info)
+		this.fail(ctx, http.StatusConflict, "upload incomplete offset:" + strconv.FormatInt(info.Offset, 10) + " length:" + strconv.FormatInt(info.Length, 10))
+		return
+	}
+
+	if info.File == nil {
+		if code, err := this.assemble(ctx, info); err != nil {
+			this.fail(ctx, code, err.Error())
+			return
+		}
+	}
+
+	this.header(ctx, info)
+
+	ctx.Json(Result{Code: http.StatusOK, Msg: "ok", Data: info.File})
+}
+
+func (this *resumable) terminate(ctx *Context) {
+	id := ctx.PathParam["id"]
+
+	mutex := this.lock(id)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if this.load(id) == nil {
+		this.fail(ctx, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	this.remove(id)
+	this.header(ctx, nil)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (this *resumable) override(ctx *Context) {
+	if strings.ToUpper(ctx.Request.Header.Get("X-HTTP-Method-Override")) == "DELETE" {
+		this.terminate(ctx)
+		return
+	}
+
+	this.patch(ctx)
+}
+
+func (this *Router) Resumable(path string, args ...ResumableOption) *Route {
+	option := ResumableOption{}
+	if len(args) > 0 {
+		option = args[0]
+	}
+
+	if option.Temp == "" {
+		option.Temp = ROOT_PATH + "/runtime/resumable"
+	}
+
+	if option.Expire <= 0 {
+		option.Expire = 24 * time.Hour
+	}
+
+	if option.Max <= 0 {
+		option.Max = 1 << 30
+	}
+
+	option.UploadOption = uploadOption([]UploadOption{option.UploadOption})
+
+	handler := &resumable{path: this.prefix + strings.TrimRight(path, "/"), option: option}
+
+	this.table.mutex.Lock()
+	this.table.resumables = append(this.table.resumables, handler)
+	this.table.mutex.Unlock()
+
+	this.handler(path + "/:id", map[string]Handler{
+		"HEAD": handler.head,
+		"GET": handler.status,
+		"PATCH": handler.patch,
+		"POST": handler.override,
+		"DELETE": handler.terminate,
+	})
+
+	this.POST(path + "/:id/complete", handler.complete)
+
+	return this.handler(path, map[string]Handler{"POST": handler.create, "OPTIONS": handler.options})
+}
@@ -0,0 +1,57 @@
+package tec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestResumableCollect(t *testing.T) {
+	app := New()
+	temp := t.TempDir()
+
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 5; i++ {
+		app.Router.Resumable("/files", ResumableOption{Temp: temp, Expire: time.Hour})
+	}
+
+	if runtime.NumGoroutine() > before {
+		t.Errorf("Resumable started goroutines: %d -> %d", before, runtime.NumGoroutine())
+	}
+
+	old := filepath.Join(temp, "0123456789abcdef0123456789abcdef.bin")
+	fresh := filepath.Join(temp, "fedcba9876543210fedcba9876543210.bin")
+	other := filepath.Join(temp, "keep.bin")
+
+	for _, file := range []string{old, fresh, other} {
+		ioutil.WriteFile(file, []byte("chunk"), 0644)
+	}
+
+	past := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(old, past, past)
+	os.Chtimes(other, past, past)
+
+	app.Router.table.resumables[0].collect()
+
+	if IsFile(old) || !IsFile(fresh) || !IsFile(other) {
+		t.Errorf("expect only the expired orphan removed: old=%v fresh=%v other=%v", IsFile(old), IsFile(fresh), IsFile(other))
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		app.resumableCollect()
+		close(finished)
+	}()
+
+	close(app.done)
+
+	select {
+	case <- finished:
+	case <- time.After(time.Second):
+		t.Error("collector did not stop on close")
+	}
+}
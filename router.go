@@ -1,60 +1,446 @@
 package tec
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 )
 
+type routeNode struct {
+	children map[string]*routeNode
+	param *routeNode
+	wildcard *routeNode
+
+	name string
+	handlers map[string]Handler
+}
+
+func (this *routeNode) insert(segments []string) *routeNode {
+	node := this
+
+	for i, segment := range segments {
+		switch {
+		case segment != "" && segment[0] == ':':
+			if node.param == nil {
+				node.param = &routeNode{name: segment[1:]}
+			} else if node.param.name != segment[1:] {
+				panic("router conflict param :" + segment[1:] + " with :" + node.param.name)
+			}
+
+			node = node.param
+		case segment != "" && segment[0] == '*':
+			if i != len(segments) - 1 {
+				panic("router catch-all *" + segment[1:] + " must be the last segment")
+			}
+
+			if node.wildcard == nil {
+				node.wildcard = &routeNode{name: segment[1:]}
+			} else if node.wildcard.name != segment[1:] {
+				panic("router conflict catch-all *" + segment[1:] + " with *" + node.wildcard.name)
+			}
+
+			node = node.wildcard
+		default:
+			if node.children == nil {
+				node.children = map[string]*routeNode{}
+			}
+
+			if node.children[segment] == nil {
+				node.children[segment] = &routeNode{}
+			}
+
+			node = node.children[segment]
+		}
+	}
+
+	return node
+}
+
+func (this *routeNode) match(segments []string, params map[string]string, depth int) *routeNode {
+	if len(segments) == 0 {
+		if this.handlers != nil {
+			return this
+		}
+
+		if depth < 3 {
+			name := "index"
+			if depth == 0 {
+				name = "home"
+			}
+
+			if child, ok := this.children[name]; ok {
+				if node := child.match(segments, params, depth + 1); node != nil {
+					return node
+				}
+			}
+		}
+
+		if this.wildcard != nil && this.wildcard.handlers != nil {
+			params[this.wildcard.name] = ""
+			return this.wildcard
+		}
+
+		return nil
+	}
+
+	if child, ok := this.children[segments[0]]; ok {
+		if node := child.match(segments[1:], params, depth + 1); node != nil {
+			return node
+		}
+	}
+
+	if this.param != nil && segments[0] != "" {
+		if node := this.param.match(segments[1:], params, depth + 1); node != nil {
+			params[this.param.name] = segments[0]
+			return node
+		}
+	}
+
+	if this.wildcard != nil && this.wildcard.handlers != nil {
+		params[this.wildcard.name] = strings.Join(segments, "/")
+		return this.wildcard
+	}
+
+	if depth == 3 && this.handlers != nil {
+		return this
+	}
+
+	return nil
+}
+
+type Route struct {
+	Path string
+	Methods []string
+
+	name string
+	table *routeTable
+}
+
+func (this *Route) Name(name string) *Route {
+	this.name = name
+	this.table.names[name] = this
+
+	return this
+}
+
+type routeTable struct {
+	root *routeNode
+	names map[string]*Route
+	routes []*Route
+
+	mutex sync.Mutex
+	resumables []*resumable
+}
+
 type Router struct {
-	data map[string]map[string]Handler
+	table *routeTable
+
+	prefix string
+	parent *Router
+
+	beforeFilter []BeforeFilterFunc
+	afterFilter []AfterFilterFunc
+}
+
+func (this *Router) init() {
+	if this.table == nil {
+		this.table = &routeTable{root: &routeNode{}, names: map[string]*Route{}, routes: []*Route{}}
+	}
+}
+
+func (this *Router) Group(prefix string, filters ...BeforeFilterFunc) *Router {
+	this.init()
+
+	if prefix == "/" {
+		prefix = ""
+	}
+
+	return &Router{
+		table: this.table,
+		prefix: this.prefix + prefix,
+		parent: this,
+		beforeFilter: filters,
+		afterFilter: []AfterFilterFunc{},
+	}
+}
+
+func (this *Router) Before(filter BeforeFilterFunc) {
+	this.beforeFilter = append(this.beforeFilter, filter)
+}
+
+func (this *Router) Use(filter BeforeFilterFunc) {
+	this.Before(filter)
+}
+
+func (this *Router) After(filter AfterFilterFunc) {
+	this.afterFilter = append(this.afterFilter, filter)
+}
+
+func (this *Router) filter(ctx *Context) bool {
+	if this.parent != nil && !this.parent.filter(ctx) {
+		return false
+	}
+
+	for i := 0; i < len(this.beforeFilter); i++ {
+		if !this.beforeFilter[i](ctx) {
+			return false
+		}
+	}
+
+	if len(this.afterFilter) > 0 {
+		filters := make([]AfterFilterFunc, 0, len(ctx.afterFilter) + len(this.afterFilter))
+		filters = append(filters, ctx.afterFilter...)
+		ctx.afterFilter = append(filters, this.afterFilter...)
+	}
+
+	return true
+}
+
+func (this *Router) wrap(route *Route, handler Handler) Handler {
+	return func(ctx *Context) {
+		ctx.route = route.Path
+
+		if !this.filter(ctx) {
+			return
+		}
+
+		handler(ctx)
+	}
+}
+
+func (this *Router) split(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
 }
 
-func (this *Router) handler(path string, handler map[string]Handler){
+func (this *Router) handler(path string, handler map[string]Handler) *Route {
 	if path == "/" {
 		path = ""
 	}
 
-	paths := strings.Split(path, "/")
+	path = this.prefix + path
+
+	this.init()
+
+	route := &Route{Path: path, Methods: []string{}, table: this.table}
+	if route.Path == "" {
+		route.Path = "/"
+	}
+
+	if !strings.ContainsAny(path, ":*") {
+		paths := strings.Split(path, "/")
 
-	if len(paths) == 4 {
-		this.data[path] = handler
-	} else if len(paths) == 3 {
-		this.data[path + "/index"] = handler
-	} else if len(paths) == 2 {
-		this.data[path + "/index/index"] = handler
-	} else if len(paths) == 1 {
-		this.data["/home/index/index"] = handler
+		if len(paths) == 3 {
+			path += "/index"
+		} else if len(paths) == 2 {
+			path += "/index/index"
+		} else if len(paths) == 1 {
+			path = "/home/index/index"
+		}
 	}
+
+	node := this.table.root.insert(this.split(path))
+	if node.handlers == nil {
+		node.handlers = map[string]Handler{}
+	}
+
+	for method, fun := range handler {
+		node.handlers[method] = this.wrap(route, fun)
+		route.Methods = append(route.Methods, method)
+	}
+
+	sort.Strings(route.Methods)
+	this.table.routes = append(this.table.routes, route)
+
+	return route
 }
 
-func (this *Router) find(path string, method string) Handler {
-	handler := this.data[path]
-	if handler == nil {
-		return nil
+func (this *Router) find(path string, method string) (Handler, map[string]string, []string) {
+	if this.table == nil {
+		return nil, nil, nil
+	}
+
+	params := map[string]string{}
+
+	node := this.table.root.match(this.split(path), params, 0)
+	if node == nil {
+		return nil, nil, nil
+	}
+
+	if handler, ok := node.handlers[method]; ok {
+		return handler, params, nil
+	}
+
+	if handler, ok := node.handlers["GET"]; ok && method == "HEAD" {
+		return handler, params, nil
+	}
+
+	allow := []string{}
+	for key, _ := range node.handlers {
+		allow = append(allow, key)
+	}
+
+	if _, ok := node.handlers["GET"]; ok && node.handlers["HEAD"] == nil {
+		allow = append(allow, "HEAD")
+	}
+
+	if _, ok := node.handlers["OPTIONS"]; !ok {
+		allow = append(allow, "OPTIONS")
+	}
+
+	sort.Strings(allow)
+
+	return nil, params, allow
+}
+
+func (this *Router) URL(name string, params ...interface{}) string {
+	this.init()
+
+	route, ok := this.table.names[name]
+	if !ok {
+		Logger("router.URL can not find route name:" + name, "error")
+		return ""
+	}
+
+	values := map[string]string{}
+	keys := []string{}
+
+	for i := 0; i < len(params); i++ {
+		switch params[i].(type) {
+		case map[string]string:
+			for key, value := range params[i].(map[string]string) {
+				values[key] = value
+				keys = append(keys, key)
+			}
+		case map[string]interface{}:
+			for key, value := range params[i].(map[string]interface{}) {
+				values[key] = fmt.Sprint(value)
+				keys = append(keys, key)
+			}
+		default:
+			if i + 1 < len(params) {
+				key := fmt.Sprint(params[i])
+				values[key] = fmt.Sprint(params[i + 1])
+				keys = append(keys, key)
+				i++
+			}
+		}
+	}
+
+	segments := this.split(route.Path)
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+
+		value, ok := values[segment[1:]]
+		if !ok {
+			Logger("router.URL route name:" + name + " missing param:" + segment[1:], "error")
+			return ""
+		}
+
+		if segment[0] == ':' {
+			segments[i] = url.PathEscape(value)
+		} else {
+			parts := strings.Split(strings.Trim(value, "/"), "/")
+			for j := 0; j < len(parts); j++ {
+				parts[j] = url.PathEscape(parts[j])
+			}
+
+			segments[i] = strings.Join(parts, "/")
+		}
+
+		delete(values, segment[1:])
+	}
+
+	path := strings.Builder{}
+
+	if CONFIG != nil && CONFIG.Gateway != nil && CONFIG.Gateway.Enable && CONFIG.App != nil {
+		path.WriteString("/")
+		path.WriteString(CONFIG.App.Name)
+	}
+
+	path.WriteString("/")
+	path.WriteString(strings.TrimRight(strings.Join(segments, "/"), "/"))
+
+	query := url.Values{}
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			query.Set(key, value)
+		}
+	}
+
+	if len(query) > 0 {
+		path.WriteString("?")
+		path.WriteString(query.Encode())
 	}
 
-	return handler[method]
+	return path.String()
+}
+
+func (this *Router) Add(path string, handler Handler) *Route {
+	return this.REQUEST(path, handler)
+}
+
+func (this *Router) REQUEST(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"GET": handler, "POST": handler})
+}
+
+func (this *Router) GET(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"GET": handler})
+}
+
+func (this *Router) POST(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"POST": handler})
 }
 
-func (this *Router) Add(path string, handler Handler) {
-	this.REQUEST(path, handler)
+func (this *Router) PUT(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"PUT": handler})
 }
 
-func (this *Router) REQUEST(path string, handler Handler) {
-	this.handler(path,  map[string]Handler{"GET": handler, "POST": handler})
+func (this *Router) DELETE(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"DELETE": handler})
 }
 
-func (this *Router) GET(path string, handler Handler) {
-	this.handler(path,  map[string]Handler{"GET": handler})
+func (this *Router) PATCH(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"PATCH": handler})
 }
 
-func (this *Router) POST(path string, handler Handler) {
-	this.handler(path,  map[string]Handler{"POST": handler})
+func (this *Router) HEAD(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"HEAD": handler})
 }
 
-func (this *Router) PUT(path string, handler Handler) {
-	this.handler(path,  map[string]Handler{"PUT": handler})
+func (this *Router) OPTIONS(path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{"OPTIONS": handler})
 }
 
-func (this *Router) DELETE(path string, handler Handler) {
-	this.handler(path,  map[string]Handler{"DELETE": handler})
-}
\ No newline at end of file
+func (this *Router) Handle(method string, path string, handler Handler) *Route {
+	return this.handler(path,  map[string]Handler{strings.ToUpper(method): handler})
+}
+
+func (this *Router) WS(path string) *Route {
+	this.handler(path + "/push", map[string]Handler{"GET": routeWSPush, "POST": routeWSPush})
+
+	return this.GET(path, func(ctx *Context) {
+		if ctx.app == nil || !ctx.IsWebsocket {
+			ctx.Status(http.StatusBadRequest)
+			ctx.Json(Result{Code: http.StatusBadRequest, Msg: "websocket upgrade required path:" + ctx.Path})
+			return
+		}
+
+		ctx.app.websocket().Serve(ctx.Response, ctx.Request, ctx)
+	})
+}
+
+func routeWSPush(ctx *Context) {
+	if ctx.app != nil {
+		ctx.app.websocket().HandlePUSH(ctx.Response, ctx.Request)
+	}
+}
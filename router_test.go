@@ -0,0 +1,61 @@
+package tec
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterStaticBeforeParam(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.GET("/user/:id", func(ctx *Context) {
+		ctx.Text("user:" + ctx.PathParam["id"])
+	})
+
+	app.Router.GET("/user/list", func(ctx *Context) {
+		ctx.Text("list")
+	})
+
+	app.Router.GET("/", func(ctx *Context) {
+		ctx.Text("home")
+	})
+
+	app.Router.GET("/shop/item/view", func(ctx *Context) {
+		ctx.Text("view")
+	})
+
+	cases := map[string]string{
+		"/user/list": "list",
+		"/user/list/index": "list",
+		"/user/5": "user:5",
+		"/": "home",
+		"/home/index/index": "home",
+		"/shop/item/view/12": "view",
+	}
+
+	for path, expect := range cases {
+		rep := httptest.NewRecorder()
+		app.Handler(rep, httptest.NewRequest("GET", path, nil))
+
+		if rep.Body.String() != expect {
+			t.Errorf("%s: expect %q, got %q", path, expect, rep.Body.String())
+		}
+	}
+}
+
+func TestRouterPathParamPrecedence(t *testing.T) {
+	app := New()
+	CONFIG = app.Config
+
+	app.Router.GET("/files/:name", func(ctx *Context) {
+		ctx.Text(ctx.Param["name"])
+	})
+
+	rep := httptest.NewRecorder()
+	app.Handler(rep, httptest.NewRequest("GET", "/files/x?name=evil", nil))
+
+	if rep.Body.String() != "x" {
+		t.Errorf("expect path param x, got %q", rep.Body.String())
+	}
+}
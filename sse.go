@@ -0,0 +1,191 @@
+package tec
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SSEEvent struct {
+	Id string
+	Event string
+	Retry int
+	Data interface{}
+}
+
+type SSE struct {
+	ctx *Context
+	mutex sync.Mutex
+	done chan struct{}
+	once sync.Once
+}
+
+func (this *SSE) write(text string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	select {
+	case <- this.done:
+		return errors.New("sse closed")
+	default:
+	}
+
+	_, err := io.WriteString(this.ctx.Response, text)
+	if err != nil {
+		this.once.Do(func() {
+			close(this.done)
+		})
+
+		return err
+	}
+
+	this.ctx.Flush()
+
+	return nil
+}
+
+func (this *SSE) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <- ticker.C:
+			this.write(": ping\n\n")
+		case <- this.ctx.Request.Context().Done():
+			this.Close()
+			return
+		case <- this.done:
+			return
+		}
+	}
+}
+
+func (this *SSE) Send(event SSEEvent) error {
+	text := strings.Builder{}
+
+	if event.Id != "" {
+		text.WriteString("id: ")
+		text.WriteString(strings.Replace(event.Id, "\n", "", -1))
+		text.WriteString("\n")
+	}
+
+	if event.Event != "" {
+		text.WriteString("event: ")
+		text.WriteString(strings.Replace(event.Event, "\n", "", -1))
+		text.WriteString("\n")
+	}
+
+	if event.Retry > 0 {
+		text.WriteString("retry: ")
+		text.WriteString(strconv.Itoa(event.Retry))
+		text.WriteString("\n")
+	}
+
+	data, ok := event.Data.(string)
+	if !ok && event.Data != nil {
+		data = JsonEncode(event.Data)
+	}
+
+	for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		text.WriteString("data: ")
+		text.WriteString(line)
+		text.WriteString("\n")
+	}
+
+	text.WriteString("\n")
+
+	return this.write(text.String())
+}
+
+func (this *SSE) Data(data interface{}) error {
+	return this.Send(SSEEvent{Data: data})
+}
+
+func (this *SSE) Done() <-chan struct{} {
+	return this.done
+}
+
+func (this *SSE) Close() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.once.Do(func() {
+		close(this.done)
+	})
+}
+
+func (this *Context) SSE(args ...time.Duration) *SSE {
+	if this.sse != nil {
+		return this.sse
+	}
+
+	interval := 15 * time.Second
+	if len(args) > 0 && args[0] > 0 {
+		interval = args[0]
+	}
+
+	if compress, ok := this.Response.(*compressWriter); ok {
+		compress.Disable()
+	}
+
+	header := this.Response.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+
+	this.writeHeader()
+	this.Flush()
+
+	this.sse = &SSE{ctx: this, done: make(chan struct{})}
+
+	go this.sse.heartbeat(interval)
+
+	return this.sse
+}
+
+func (this *Context) Stream(reader io.Reader, args ...string) error {
+	header := this.Response.Header()
+	if len(args) > 0 {
+		header.Set("Content-Type", args[0])
+	} else if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+
+	this.writeHeader()
+
+	buffer := make([]byte, 32 * 1024)
+
+	for {
+		select {
+		case <- this.Request.Context().Done():
+			return this.Request.Context().Err()
+		default:
+		}
+
+		num, err := reader.Read(buffer)
+		if num > 0 {
+			if _, err := this.Response.Write(buffer[0:num]); err != nil {
+				return err
+			}
+
+			this.Flush()
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			this.Logger("context.Stream error:" + err.Error(), "error")
+			return err
+		}
+	}
+}
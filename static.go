@@ -0,0 +1,174 @@
+package tec
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type staticHandler struct {
+	prefix string
+	root string
+	spa bool
+	cache map[string]int
+}
+
+func (this *staticHandler) open(file string) (*os.File, os.FileInfo) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, nil
+	}
+
+	info, err := fd.Stat()
+	if err != nil || info.IsDir() {
+		fd.Close()
+		return nil, nil
+	}
+
+	return fd, info
+}
+
+func (this *staticHandler) cacheControl(ext string) string {
+	seconds, ok := this.cache[ext]
+	if !ok {
+		seconds, ok = this.cache["default"]
+	}
+
+	if !ok {
+		return ""
+	}
+
+	if seconds <= 0 {
+		return "no-cache"
+	}
+
+	return "public, max-age=" + strconv.Itoa(seconds)
+}
+
+func (this *staticHandler) resolve(url string) string {
+	name := path.Clean("/" + strings.TrimPrefix(url, this.prefix))
+
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return ""
+		}
+	}
+
+	file := filepath.Join(this.root, filepath.FromSlash(name))
+
+	if IsDir(file) {
+		file = filepath.Join(file, "index.html")
+	}
+
+	if IsFile(file) && !IsDir(file) {
+		return file
+	}
+
+	if this.spa && path.Ext(name) == "" && IsFile(filepath.Join(this.root, "index.html")) {
+		return filepath.Join(this.root, "index.html")
+	}
+
+	return ""
+}
+
+func (this *staticHandler) ServeHTTP(rep http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		rep.Header().Set("Allow", "GET, HEAD")
+		http.Error(rep, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file := this.resolve(req.URL.Path)
+	if file == "" {
+		http.NotFound(rep, req)
+		return
+	}
+
+	ext := FileExt(file)
+
+	header := rep.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	if cache := this.cacheControl(ext); cache != "" {
+		header.Set("Cache-Control", cache)
+	}
+
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	exts := map[string]string{"br": ".br", "gzip": ".gz"}
+
+	offers := []string{}
+	for _, encoding := range []string{"br", "gzip"} {
+		if IsFile(file + exts[encoding]) {
+			offers = append(offers, encoding)
+		}
+	}
+
+	if encoding := compressEncoding(req.Header.Get("Accept-Encoding"), offers...); encoding != "" {
+		if fd, info := this.open(file + exts[encoding]); fd != nil {
+			defer fd.Close()
+
+			header.Set("Content-Encoding", encoding)
+			header.Set("ETag", "\"" + strconv.FormatInt(info.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(info.Size(), 16) + "-" + encoding + "\"")
+
+			http.ServeContent(rep, req, filepath.Base(file), info.ModTime(), fd)
+			return
+		}
+	}
+
+	fd, info := this.open(file)
+	if fd == nil {
+		http.NotFound(rep, req)
+		return
+	}
+
+	defer fd.Close()
+
+	header.Set("ETag", "\"" + strconv.FormatInt(info.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(info.Size(), 16) + "\"")
+
+	http.ServeContent(rep, req, filepath.Base(file), info.ModTime(), fd)
+}
+
+func staticMounts(config *Config) []*staticHandler {
+	cache := map[string]int{}
+	mounts := map[string]string{}
+
+	if config.Static != nil {
+		for key, value := range config.Static.Cache {
+			cache[key] = value
+		}
+
+		for key, value := range config.Static.Mounts {
+			mounts[key] = value
+		}
+	}
+
+	if config.App != nil && config.App.Static != "" {
+		if _, ok := mounts["/static"]; !ok {
+			mounts["/static"] = config.App.Static
+		}
+	}
+
+	handlers := []*staticHandler{}
+	for prefix, value := range mounts {
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+
+		handlers = append(handlers, &staticHandler{
+			prefix: strings.TrimRight(prefix, "/"),
+			root: fields[0],
+			spa: len(fields) > 1 && strings.ToLower(fields[1]) == "spa",
+			cache: cache,
+		})
+	}
+
+	return handlers
+}
@@ -0,0 +1,62 @@
+package tec
+
+import (
+	"github.com/agilecho/tec/metrics"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticPrecompressed(t *testing.T) {
+	root, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(root)
+
+	for name, content := range map[string]string{"app.js": "plain", "app.js.br": "br", "app.js.gz": "gzip"} {
+		ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+	}
+
+	handler := &staticHandler{prefix: "/static", root: root}
+
+	for accept, expect := range map[string]string{"br;q=0, gzip": "gzip", "gzip;q=0.5, br": "br", "deflate": "plain", "": "plain"} {
+		req := httptest.NewRequest("GET", "/static/app.js", nil)
+		req.Header.Set("Accept-Encoding", accept)
+
+		rep := httptest.NewRecorder()
+		handler.ServeHTTP(rep, req)
+
+		if rep.Body.String() != expect {
+			t.Errorf("%q: expect %q, got %q", accept, expect, rep.Body.String())
+		}
+	}
+}
+
+func TestStaticMountThroughHandler(t *testing.T) {
+	root := t.TempDir()
+	ioutil.WriteFile(filepath.Join(root, "app.js"), []byte("plain"), 0644)
+
+	app := New()
+	CONFIG = app.Config
+	app.statics = []*staticHandler{{prefix: "/assets", root: root}}
+
+	app.Router.GET("/assets/app.js", func(ctx *Context) {
+		ctx.Text("route")
+	})
+
+	rep := httptest.NewRecorder()
+	app.Handler(rep, httptest.NewRequest("GET", "/assets/app.js", nil))
+
+	if rep.Body.String() != "plain" || rep.Header().Get("X-Request-Id") == "" {
+		t.Errorf("expect static file with request id, got %q %v", rep.Body.String(), rep.Header())
+	}
+
+	if !strings.Contains(metrics.Text(), `route="static"`) {
+		t.Error("static request missing from metrics")
+	}
+}
@@ -207,7 +207,7 @@ func Sha256(data string) string {
 }
 
 func Chr(ascii int) string {
-	return string(ascii)
+	return string(rune(ascii))
 }
 
 func Ord(char string) int {
@@ -609,7 +609,7 @@ func IsEmail(data string) bool {
 }
 
 func IsImage(data string) bool {
-	return InArray(FileExt(data), []string{"jpg", "jpeg", "gif", "bmp", "png"})
+	return InArray(FileExt(data), []string{".jpg", ".jpeg", ".gif", ".bmp", ".png", ".webp"})
 }
 
 func IsIP(data string) bool {
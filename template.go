@@ -0,0 +1,258 @@
+package tec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type templateEntry struct {
+	tpl *template.Template
+	name string
+	files map[string]time.Time
+}
+
+type templateManager struct {
+	mutex sync.RWMutex
+	entries map[string]*templateEntry
+	funcs template.FuncMap
+	app *App
+}
+
+func newTemplateManager(app *App) *templateManager {
+	return &templateManager{entries: map[string]*templateEntry{}, funcs: template.FuncMap{}, app: app}
+}
+
+type GlobalFunc func(ctx *Context) map[string]interface{}
+
+var templateBuffers = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+func templateFuncs(router *Router) template.FuncMap {
+	return template.FuncMap{
+		"Println": fmt.Println,
+		"Sprintf": fmt.Sprintf,
+
+		"IdEnCode": IdEnCode,
+		"IdDeCode": IdDeCode,
+		"UcFirst": UcFirst,
+		"StripWords": StripWords,
+		"CutString": CutString,
+		"SubTimer": SubTimer,
+		"TimeSpan": TimeSpan,
+		"StripTags": StripTags,
+
+		"Add": Add,
+		"Subtract": Subtract,
+		"Multiply": Multiply,
+		"Divide": Divide,
+		"Round": Round,
+		"Floor": Floor,
+		"Ceil": Ceil,
+		"Max": Max,
+		"Min": Min,
+		"Rand": Rand,
+		"Random": Random,
+
+		"FormatInt": FormatInt,
+		"FormatInt64": FormatInt64,
+		"FormatFloat64": FormatFloat64,
+		"FormatBytes": FormatBytes,
+		"FormatDiscount": FormatDiscount,
+		"FormatMobilePrivacy": FormatMobilePrivacy,
+		"FormatPrice": FormatPrice,
+		"FormatTime": FormatTime,
+
+		"Loop": Loop,
+		"Pager": Pager,
+
+		"URL": func(name string, params ...interface{}) string {
+			if router == nil {
+				return ""
+			}
+
+			return router.URL(name, params...)
+		},
+
+		"EQ": func(param map[string]string, key, value string) bool {
+			if val, ok := param[key]; ok {
+				return  value == val
+			}
+
+			return false
+		},
+	}
+}
+
+func (this *templateManager) parse(main string, names []string) (*templateEntry, error) {
+	entry := &templateEntry{name: main + CONFIG.Template.Extension, files: map[string]time.Time{}}
+
+	var router *Router
+	if this.app != nil {
+		router = this.app.Router
+	}
+
+	this.mutex.RLock()
+	tpl := template.New(main).Funcs(templateFuncs(router)).Funcs(this.funcs)
+	this.mutex.RUnlock()
+
+	for _, name := range names {
+		path := CONFIG.Template.Path + name + CONFIG.Template.Extension
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.New("can not find template file path:" + name)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		item, err := tpl.New(name + CONFIG.Template.Extension).Parse(string(content))
+		if err != nil {
+			return nil, err
+		}
+
+		if base := BaseName(path); tpl.Lookup(base) == nil {
+			if _, err = tpl.AddParseTree(base, item.Tree); err != nil {
+				return nil, err
+			}
+		}
+
+		entry.files[path] = info.ModTime()
+	}
+
+	entry.tpl = tpl
+
+	return entry, nil
+}
+
+func (this *templateEntry) changed() bool {
+	for path, modtime := range this.files {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modtime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *templateManager) get(main string, names []string) (*templateEntry, error) {
+	if !CONFIG.Template.Cache {
+		return this.parse(main, names)
+	}
+
+	key := main + ":" + strings.Join(names, ",")
+
+	this.mutex.RLock()
+	entry, ok := this.entries[key]
+	this.mutex.RUnlock()
+
+	if ok && !(CONFIG.App != nil && CONFIG.App.Debug && entry.changed()) {
+		return entry, nil
+	}
+
+	entry, err := this.parse(main, names)
+	if err != nil {
+		return nil, err
+	}
+
+	this.mutex.Lock()
+	this.entries[key] = entry
+	this.mutex.Unlock()
+
+	return entry, nil
+}
+
+func (this *templateManager) Reset() {
+	this.mutex.Lock()
+	this.entries = map[string]*templateEntry{}
+	this.mutex.Unlock()
+}
+
+func (this *templateManager) Funcs(funcs template.FuncMap) {
+	this.mutex.Lock()
+	for name, fun := range funcs {
+		this.funcs[name] = fun
+	}
+
+	this.entries = map[string]*templateEntry{}
+	this.mutex.Unlock()
+}
+
+func (this *App) TemplateReset() {
+	this.templates.Reset()
+}
+
+func (this *App) Funcs(funcs template.FuncMap) {
+	this.templates.Funcs(funcs)
+}
+
+func (this *App) Globals(fun GlobalFunc) {
+	this.globalFunc = append(this.globalFunc, fun)
+}
+
+func (this *Context) templateGlobals(data map[string]interface{}) {
+	if this.app == nil {
+		return
+	}
+
+	for _, fun := range this.app.globalFunc {
+		for key, value := range fun(this) {
+			if _, ok := data[key]; !ok {
+				data[key] = value
+			}
+		}
+	}
+}
+
+func (this *Context) Layout(name string) {
+	this.layout = name
+	this.layouted = true
+}
+
+func (this *Context) templateNames(file string) (string, []string) {
+	layout := CONFIG.Template.Layout
+	if this.layouted {
+		layout = this.layout
+	}
+
+	main := file
+
+	names := []string{}
+	if layout != "" {
+		main = "/" + strings.TrimLeft(layout, "/")
+		names = append(names, main)
+	}
+
+	for _, value := range strings.Split(CONFIG.Template.Define, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			names = append(names, value)
+		}
+	}
+
+	return main, append(names, file)
+}
+
+func (this *Context) templateError(err error) {
+	this.Logger("context.Render error:" + err.Error(), "error")
+
+	msg := "template error"
+	if CONFIG.App != nil && CONFIG.App.Debug {
+		msg = err.Error()
+	}
+
+	this.Status(500)
+	this.Json(Result{Code: 500, Msg: msg})
+}
@@ -0,0 +1,57 @@
+package tec
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplatePerApp(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "page.html"), []byte(`{{Name}} {{URL "detail"}}`), 0644)
+
+	apps := []*App{}
+	for _, name := range []string{"first", "second"} {
+		app := New()
+		app.Config.Template = &configOfTemplate{Path: dir, Extension: ".html", Cache: true}
+		CONFIG = app.Config
+
+		value := name
+		app.Funcs(template.FuncMap{"Name": func() string {
+			return value
+		}})
+
+		app.Router.GET("/page", func(ctx *Context) {
+			ctx.Render("/page", nil)
+		})
+
+		app.Router.GET("/" + name, func(ctx *Context) {}).Name("detail")
+
+		apps = append(apps, app)
+	}
+
+	entries := map[*App]*templateEntry{}
+
+	for i := 0; i < 3; i++ {
+		for index, app := range apps {
+			CONFIG = app.Config
+
+			rep := httptest.NewRecorder()
+			app.Handler(rep, httptest.NewRequest("GET", "/page", nil))
+
+			expect := []string{"first /first", "second /second"}[index]
+			if rep.Body.String() != expect {
+				t.Fatalf("expect %q, got %q", expect, rep.Body.String())
+			}
+
+			entry := app.templates.entries["/page:/page"]
+			if entry == nil || (entries[app] != nil && entries[app] != entry) {
+				t.Errorf("template cache rebuilt for app %d", index)
+			}
+
+			entries[app] = entry
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tec
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type tlsReloader struct {
+	cert string
+	key string
+	ca string
+
+	mutex sync.RWMutex
+	certificate *tls.Certificate
+	pool *x509.CertPool
+	modified time.Time
+}
+
+func (this *tlsReloader) modTime() time.Time {
+	modified := time.Time{}
+
+	for _, file := range []string{this.cert, this.key, this.ca} {
+		if file == "" {
+			continue
+		}
+
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+
+	return modified
+}
+
+func (this *tlsReloader) load() error {
+	modified := this.modTime()
+
+	certificate, err := tls.LoadX509KeyPair(this.cert, this.key)
+	if err != nil {
+		return err
+	}
+
+	var pool *x509.CertPool
+	if this.ca != "" {
+		data, err := ioutil.ReadFile(this.ca)
+		if err != nil {
+			return err
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("can not parse client ca file:" + this.ca)
+		}
+	}
+
+	this.mutex.Lock()
+	this.certificate = &certificate
+	this.pool = pool
+	this.modified = modified
+	this.mutex.Unlock()
+
+	return nil
+}
+
+func (this *tlsReloader) watch(done chan struct{}) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <- ticker.C:
+			this.mutex.RLock()
+			modified := this.modified
+			this.mutex.RUnlock()
+
+			if !this.modTime().After(modified) {
+				continue
+			}
+
+			if err := this.load(); err != nil {
+				Logger("tls reload cert:" + this.cert + " error:" + err.Error(), "error", "false")
+			} else {
+				Logger("tls reload cert:" + this.cert, "info", "false")
+			}
+		case <- done:
+			return
+		}
+	}
+}
+
+func (this *tlsReloader) config(version uint16) *tls.Config {
+	return &tls.Config{
+		MinVersion: version,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			this.mutex.RLock()
+			defer this.mutex.RUnlock()
+
+			return this.certificate, nil
+		},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			this.mutex.RLock()
+			defer this.mutex.RUnlock()
+
+			config := &tls.Config{
+				MinVersion: version,
+				Certificates: []tls.Certificate{*this.certificate},
+				NextProtos: []string{"h2", "http/1.1"},
+			}
+
+			if this.pool != nil {
+				config.ClientCAs = this.pool
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+
+			return config, nil
+		},
+	}
+}
+
+func tlsVersion(version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, errors.New("tls version not supported:" + version)
+	}
+}
+
+func tlsRedirect(port int) http.Handler {
+	return http.HandlerFunc(func(rep http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if hostPart, _, err := net.SplitHostPort(host); err == nil {
+			host = hostPart
+		}
+
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		http.Redirect(rep, req, "https://" + host + req.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+func (this *App) prepare(host string, port int, cert string, key string, ca string, version string, redirect int) error {
+	this.srv = http.Server{Addr: host + ":" + strconv.Itoa(port)}
+
+	if cert == "" || key == "" {
+		return nil
+	}
+
+	minVersion, err := tlsVersion(version)
+	if err != nil {
+		return err
+	}
+
+	reloader := &tlsReloader{cert: cert, key: key, ca: ca}
+	if err := reloader.load(); err != nil {
+		return err
+	}
+
+	go reloader.watch(this.done)
+
+	this.srv.TLSConfig = reloader.config(minVersion)
+
+	if redirect > 0 {
+		this.redirect = &http.Server{Addr: host + ":" + strconv.Itoa(redirect), Handler: tlsRedirect(port)}
+	}
+
+	return nil
+}
+
+func (this *App) serve() error {
+	if this.srv.TLSConfig == nil {
+		return this.srv.ListenAndServe()
+	}
+
+	if this.redirect != nil {
+		go func() {
+			err := this.redirect.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				Logger("app.redirect error:" + err.Error(), "error", "false")
+			}
+		}()
+	}
+
+	return this.srv.ListenAndServeTLS("", "")
+}
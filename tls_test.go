@@ -0,0 +1,23 @@
+package tec
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestTlsVersion(t *testing.T) {
+	for value, expect := range map[string]uint16{"": tls.VersionTLS12, "1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13} {
+		if version, err := tlsVersion(value); err != nil || version != expect {
+			t.Errorf("%q: expect %d, got %d %v", value, expect, version, err)
+		}
+	}
+
+	if _, err := tlsVersion("1.4"); err == nil {
+		t.Error("expect error for tls 1.4")
+	}
+
+	app := New()
+	if err := app.prepare("127.0.0.1", 0, "cert.pem", "key.pem", "", "1.4", 0); err == nil {
+		t.Error("expect prepare to reject tls 1.4")
+	}
+}
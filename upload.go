@@ -0,0 +1,323 @@
+package tec
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var uploadDeny = []string{".php", ".php3", ".php5", ".phtml", ".jsp", ".jspx", ".asp", ".aspx", ".cgi", ".pl", ".py", ".sh", ".bat", ".cmd", ".exe", ".dll", ".so", ".htaccess", ".html", ".htm", ".shtml", ".svg", ".js"}
+
+type UploadOption struct {
+	Path string
+	Max int64
+	Count int
+	Exts []string
+	Types []string
+	Pixels int64
+}
+
+type UploadFile struct {
+	Field string `json:"field"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Ext string `json:"ext"`
+	Size int64 `json:"size"`
+	Hash string `json:"hash"`
+	Type string `json:"type"`
+	Width int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+func uploadList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func uploadOption(args []UploadOption) UploadOption {
+	option := UploadOption{}
+	if len(args) > 0 {
+		option = args[0]
+	}
+
+	if CONFIG != nil && CONFIG.Upload != nil {
+		if option.Path == "" {
+			option.Path = CONFIG.Upload.Path
+		}
+
+		if option.Max <= 0 {
+			option.Max = CONFIG.Upload.Max
+		}
+
+		if option.Count <= 0 {
+			option.Count = CONFIG.Upload.Count
+		}
+
+		if len(option.Exts) == 0 {
+			option.Exts = uploadList(CONFIG.Upload.Exts)
+		}
+
+		if len(option.Types) == 0 {
+			option.Types = uploadList(CONFIG.Upload.Types)
+		}
+
+		if option.Pixels <= 0 {
+			option.Pixels = CONFIG.Upload.Pixels
+		}
+	}
+
+	if option.Path == "" {
+		option.Path = "/upload"
+	}
+
+	if option.Max <= 0 {
+		option.Max = 10 << 20
+	}
+
+	if option.Pixels <= 0 {
+		option.Pixels = 50000000
+	}
+
+	exts := []string{}
+	for _, ext := range option.Exts {
+		if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" && ext[0] != '.' {
+			ext = "." + ext
+		}
+
+		if ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+
+	option.Exts = exts
+
+	return option
+}
+
+func uploadPath(target string) (string, error) {
+	root, err := filepath.Abs(PUBLIC_PATH)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(root, filepath.FromSlash(filepath.ToSlash(filepath.Clean("/" + target))))
+	if !strings.HasPrefix(path, root + string(filepath.Separator)) {
+		return "", errors.New("upload path outside public:" + target)
+	}
+
+	return path, nil
+}
+
+func uploadName() string {
+	bytes := make([]byte, 16)
+	io.ReadFull(crand.Reader, bytes)
+
+	return hex.EncodeToString(bytes)
+}
+
+func uploadExt(filename string, option UploadOption) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filepath.Base(strings.Replace(filename, "\\", "/", -1))))
+
+	if len(option.Exts) > 0 {
+		if !InArray(ext, option.Exts) {
+			return "", errors.New("upload file ext not allowed:" + filename)
+		}
+	} else if InArray(ext, uploadDeny) {
+		return "", errors.New("upload file ext not allowed:" + filename)
+	}
+
+	return ext, nil
+}
+
+func uploadType(filename string, ext string, reader io.Reader, option UploadOption) (string, string, error) {
+	head := make([]byte, 512)
+	num, err := io.ReadFull(reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", err
+	}
+
+	head = head[0:num]
+
+	mime := strings.TrimSpace(strings.Split(http.DetectContentType(head), ";")[0])
+
+	if len(option.Types) > 0 {
+		allowed := false
+		for _, item := range option.Types {
+			if item == mime || (strings.HasSuffix(item, "/*") && strings.HasPrefix(mime, item[0:len(item) - 1])) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return "", "", errors.New("upload file type not allowed:" + filename + " type:" + mime)
+		}
+	} else if mime == "text/html" || mime == "text/xml" {
+		return "", "", errors.New("upload file type not allowed:" + filename + " type:" + mime)
+	}
+
+	return ext, mime, nil
+}
+
+func uploadCheck(header *multipart.FileHeader, option UploadOption) (string, string, error) {
+	if header.Size > option.Max {
+		return "", "", errors.New("upload file too large:" + header.Filename + " limit:" + strconv.FormatInt(option.Max, 10))
+	}
+
+	ext, err := uploadExt(header.Filename, option)
+	if err != nil {
+		return "", "", err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return "", "", err
+	}
+
+	defer file.Close()
+
+	return uploadType(header.Filename, ext, file, option)
+}
+
+func uploadImage(file io.ReadSeeker, filename string, mime string, option UploadOption) (int, int, string, error) {
+	config, format, err := image.DecodeConfig(file)
+	if err == image.ErrFormat {
+		return 0, 0, "", errors.New("upload image type not supported:" + filename + " type:" + mime)
+	}
+
+	if err != nil {
+		return 0, 0, "", errors.New("image can not decode:" + err.Error())
+	}
+
+	if int64(config.Width) * int64(config.Height) > option.Pixels {
+		return 0, 0, "", errors.New("image too large:" + strconv.Itoa(config.Width) + "x" + strconv.Itoa(config.Height))
+	}
+
+	file.Seek(0, io.SeekStart)
+
+	if _, _, err = image.Decode(file); err != nil {
+		return 0, 0, "", errors.New("image can not decode:" + err.Error())
+	}
+
+	if format == "jpeg" {
+		format = "jpg"
+	}
+
+	return config.Width, config.Height, "." + format, nil
+}
+
+func (this *Context) uploadSave(name string, header *multipart.FileHeader, option UploadOption) (*UploadFile, error) {
+	ext, mime, err := uploadCheck(header, option)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	width, height := 0, 0
+
+	if strings.HasPrefix(mime, "image/") || IsImage(header.Filename) {
+		if width, height, ext, err = uploadImage(file, header.Filename, mime, option); err != nil {
+			return nil, err
+		}
+
+		file.Seek(0, io.SeekStart)
+	}
+
+	dir := strings.TrimRight(filepath.ToSlash(filepath.Clean("/" + option.Path)), "/") + time.Now().Format("/2006/01/02")
+
+	path, err := uploadPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsDir(path) {
+		if err = os.MkdirAll(path, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &UploadFile{Field: name, Name: filepath.Base(strings.Replace(header.Filename, "\\", "/", -1)), Ext: ext, Type: mime, Width: width, Height: height}
+	result.Path = dir + "/" + uploadName() + ext
+
+	out, err := os.OpenFile(filepath.Join(path, filepath.Base(result.Path)), os.O_WRONLY | os.O_CREATE | os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), io.LimitReader(file, option.Max + 1))
+	out.Close()
+
+	target := filepath.Join(path, filepath.Base(result.Path))
+
+	if err == nil && size > option.Max {
+		err = errors.New("upload file too large:" + header.Filename + " limit:" + strconv.FormatInt(option.Max, 10))
+	}
+
+	if err != nil {
+		os.Remove(target)
+		return nil, err
+	}
+
+	result.Size = size
+	result.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return result, nil
+}
+
+func (this *Context) Upload(name string, args ...UploadOption) ([]*UploadFile, error) {
+	option := uploadOption(args)
+
+	headers := this.Files[name]
+	if len(headers) == 0 {
+		return nil, errors.New("upload file not found:" + name)
+	}
+
+	if option.Count > 0 && len(headers) > option.Count {
+		return nil, errors.New("upload file count limit:" + strconv.Itoa(option.Count))
+	}
+
+	files := []*UploadFile{}
+
+	for _, header := range headers {
+		file, err := this.uploadSave(name, header, option)
+		if err != nil {
+			for _, item := range files {
+				if path, err := uploadPath(item.Path); err == nil {
+					os.Remove(path)
+				}
+			}
+
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
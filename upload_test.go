@@ -0,0 +1,125 @@
+package tec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func uploadRequest(t *testing.T, filename string, content []byte) ([]*UploadFile, error) {
+	app := New()
+	CONFIG = app.Config
+
+	defer func(path string) {
+		PUBLIC_PATH = path
+	}(PUBLIC_PATH)
+
+	PUBLIC_PATH = t.TempDir()
+
+	var files []*UploadFile
+	var err error
+
+	app.Router.POST("/upload", func(ctx *Context) {
+		files, err = ctx.Upload("file")
+	})
+
+	body := bytes.Buffer{}
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	app.Handler(httptest.NewRecorder(), req)
+
+	if matches, _ := filepath.Glob(filepath.Join(PUBLIC_PATH, "upload", "*", "*", "*", "*")); err != nil && len(matches) > 0 {
+		t.Errorf("%s: rejected upload left %v", filename, matches)
+	}
+
+	return files, err
+}
+
+func TestUploadRejectsWebp(t *testing.T) {
+	webp, _ := base64.StdEncoding.DecodeString("UklGRiQAAABXRUJQVlA4IBgAAAAwAQCdASoBAAEAAwA0JaQAA3AA/vuUAAA=")
+
+	_, err := uploadRequest(t, "photo.webp", webp)
+	if err == nil || !strings.Contains(err.Error(), "upload image type not supported") || !strings.Contains(err.Error(), "image/webp") {
+		t.Errorf("expect webp rejected as unsupported, got %v", err)
+	}
+}
+
+func TestUploadRejectsJunkImage(t *testing.T) {
+	for _, name := range []string{"photo.webp", "photo.bmp", "photo.png"} {
+		if _, err := uploadRequest(t, name, []byte("junk bytes that are not an image")); err == nil {
+			t.Errorf("%s: expect junk image rejected", name)
+		}
+	}
+}
+
+func TestUploadImageExt(t *testing.T) {
+	buffer := bytes.Buffer{}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 3))
+	img.Set(0, 0, color.White)
+	png.Encode(&buffer, img)
+
+	files, err := uploadRequest(t, "photo.jpg", buffer.Bytes())
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expect png accepted, got %v", err)
+	}
+
+	if files[0].Ext != ".png" || files[0].Width != 2 || files[0].Height != 3 || !strings.HasSuffix(files[0].Path, ".png") {
+		t.Errorf("expect png 2x3, got %+v", files[0])
+	}
+}
+
+func TestUploadRegisteredDecoder(t *testing.T) {
+	image.RegisterFormat("tecimg", "TECIMG", func(reader io.Reader) (image.Image, error) {
+		ioutil.ReadAll(reader)
+		return image.NewGray(image.Rect(0, 0, 4, 4)), nil
+	}, func(reader io.Reader) (image.Config, error) {
+		if _, err := io.ReadFull(reader, make([]byte, 6)); err != nil {
+			return image.Config{}, errors.New("short")
+		}
+
+		return image.Config{ColorModel: color.GrayModel, Width: 4, Height: 4}, nil
+	})
+
+	files, err := uploadRequest(t, "photo.bmp", []byte("TECIMG"))
+	if err != nil || len(files) != 1 || files[0].Ext != ".tecimg" || files[0].Width != 4 {
+		t.Errorf("expect registered decoder accepted, got %v %v", files, err)
+	}
+}
+
+func TestUploadExtAndPath(t *testing.T) {
+	for name, allowed := range map[string]bool{"a.PNG": true, "shell.php": false, "dir\\\\evil.JSP": false, "notes.txt": true} {
+		if _, err := uploadExt(name, UploadOption{}); (err == nil) != allowed {
+			t.Errorf("%s: expect allowed %v, got %v", name, allowed, err)
+		}
+	}
+
+	if _, err := uploadExt("notes.txt", UploadOption{Exts: []string{".jpg"}}); err == nil {
+		t.Error("expect ext whitelist to reject .txt")
+	}
+
+	defer func(path string) {
+		PUBLIC_PATH = path
+	}(PUBLIC_PATH)
+
+	PUBLIC_PATH = t.TempDir()
+
+	if path, err := uploadPath("/../../etc"); err != nil || !strings.HasPrefix(path, PUBLIC_PATH) {
+		t.Errorf("expect path kept inside public, got %s %v", path, err)
+	}
+}
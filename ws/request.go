@@ -172,6 +172,7 @@ type Request struct {
 	Fid int64
 	RoomId int64
 	Server *Server
+	Context interface{}
 }
 
 func (this *Request) writeFatal(err error) error {
@@ -2,6 +2,8 @@ package ws
 
 import (
 	"bufio"
+	"context"
+	"github.com/agilecho/tec/metrics"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +12,9 @@ import (
 	"time"
 )
 
+var connections = metrics.NewGauge("ws_connections", "Number of open websocket connections.")
+var messages = metrics.NewCounter("ws_messages_total", "Total number of websocket messages received.")
+
 var serial int64 = 0
 var mutex sync.Mutex
 
@@ -30,6 +35,11 @@ type Config struct {
 	Token string
 	Origin string
 	Version int
+	Cert string
+	Key string
+	Ca string
+	Tls string
+	Redirect int
 }
 
 func (this *Config) Set(key string, value string) {
@@ -46,6 +56,16 @@ func (this *Config) Set(key string, value string) {
 		this.Origin = value
 	case "version":
 		this.Version, _ = strconv.Atoi(value)
+	case "cert":
+		this.Cert = value
+	case "key":
+		this.Key = value
+	case "ca":
+		this.Ca = value
+	case "tls":
+		this.Tls = value
+	case "redirect":
+		this.Redirect, _ = strconv.Atoi(value)
 	}
 }
 
@@ -63,12 +83,21 @@ type Server struct {
 }
 
 func (this *Server) ServeHTTP(rep http.ResponseWriter, req *http.Request) {
+	this.Serve(rep, req, nil)
+}
+
+func (this *Server) Serve(rep http.ResponseWriter, req *http.Request, context interface{}) {
 	request := this.HandleWS(rep, req)
 	if request == nil {
 		return
 	}
 
+	request.Context = context
+	connections.Inc()
+
 	defer func() {
+		connections.Dec()
+
 		mutex.Lock()
 		delete(this.Requests, request.Fid)
 		mutex.Unlock()
@@ -96,6 +125,8 @@ func (this *Server) ServeHTTP(rep http.ResponseWriter, req *http.Request) {
 			break
 		}
 
+		messages.Inc()
+
 		if messageFunc, ok := this.Events["message"]; ok {
 			switch messageFunc.(type) {
 			case func(req *Request, message string):
@@ -216,6 +247,13 @@ func (this *Server) HandlePUSH(rep http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (this *Server) Count() int {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return len(this.Requests)
+}
+
 func (this *Server) Push(fid int64, message string) {
 	if request, ok := this.Requests[fid]; ok && request != nil {
 		request.WriteMessage([]byte(message))
@@ -237,3 +275,40 @@ func (this *Server) Broadcast(message string) {
 		}
 	}
 }
+
+func (this *Server) Shutdown(ctx context.Context) {
+	mutex.Lock()
+	for _, request := range this.Requests {
+		if request != nil {
+			request.WriteControl(CloseMessage, FormatCloseMessage(CloseGoingAway, "server shutdown"), time.Now().Add(writeWait))
+		}
+	}
+	mutex.Unlock()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		mutex.Lock()
+		count := len(this.Requests)
+		mutex.Unlock()
+
+		if count == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			mutex.Lock()
+			for _, request := range this.Requests {
+				if request != nil {
+					request.Close()
+				}
+			}
+			mutex.Unlock()
+
+			return
+		case <-ticker.C:
+		}
+	}
+}